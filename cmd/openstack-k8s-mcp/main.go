@@ -1,207 +1,173 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/dprince/openstack-k8s-mcp/internal/client"
+	"github.com/dprince/openstack-k8s-mcp/internal/config"
 	"github.com/dprince/openstack-k8s-mcp/internal/handlers"
+	"github.com/dprince/openstack-k8s-mcp/internal/operations"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 )
 
+const (
+	transportStdio = "stdio"
+	transportHTTP  = "http"
+)
+
 func main() {
-	// Initialize Kubernetes client
-	k8sClient, err := client.NewK8sClient()
+	transport := flag.String("transport", transportStdio, "Transport to serve MCP over: 'stdio' or 'http' (streamable HTTP/SSE)")
+	listenAddr := flag.String("listen", ":8080", "Listen address for the http transport")
+	endpointPath := flag.String("path", "/mcp", "Endpoint path for the http transport")
+	configPath := flag.String("config", "", fmt.Sprintf("Path to the YAML config file (default: $%s)", config.EnvConfigPath))
+	readOnly := flag.Bool("read-only", false, "Omit every mutating tool and refuse Patch/Create calls in the Kubernetes client")
+	flag.Parse()
+
+	if *transport != transportStdio && *transport != transportHTTP {
+		log.Fatalf("Invalid --transport '%s': must be '%s' or '%s'", *transport, transportStdio, transportHTTP)
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	// Server-wide defaults passed to every handler
+	settings := handlers.Settings{
+		DefaultNamespace:      cfg.DefaultNamespace,
+		WaitTimeout:           cfg.Wait.Timeout,
+		WaitPollInterval:      cfg.Wait.PollInterval,
+		DeploymentRequeueTime: cfg.DeploymentRequeueTime,
+	}
+
+	// Initialize a Kubernetes client for every cluster
+	clusters := []client.Cluster{}
+	for _, cluster := range cfg.ClusterList() {
+		clusters = append(clusters, client.Cluster{
+			Name:        cluster.Name,
+			KubeContext: cluster.KubeContext,
+			Kubeconfig:  cluster.Kubeconfig,
+		})
+	}
+
+	registry, err := client.NewRegistry(clusters, cfg.DefaultClusterName(), client.Options{
+		ReadOnly:         *readOnly,
+		WaitTimeout:      cfg.Wait.Timeout,
+		WaitPollInterval: cfg.Wait.PollInterval,
+	})
 	if err != nil {
 		log.Fatalf("Failed to create Kubernetes client: %v", err)
 	}
 
-	// Create MCP server
+	// Create MCP server. Resource subscriptions are wired up through hooks
+	// once the server exists.
+	hooks := &server.Hooks{}
 	s := server.NewMCPServer(
 		"openstack-k8s-mcp",
 		"1.0.0",
+		server.WithResourceCapabilities(true, false),
+		server.WithPromptCapabilities(false),
+		server.WithHooks(hooks),
 	)
+	registerSubscriptionHooks(hooks, handlers.NewResourceSubscriptions(registry, s))
 
-	// Register the get_openstack_version tool
-	getOpenStackVersionTool := mcp.NewTool("get_openstack_version",
-		mcp.WithDescription("Get OpenStack version information including targetVersion, availableVersion, deployedVersion, and conditions."),
-		mcp.WithString("namespace",
-			mcp.Description("Namespace (default: openstack)"),
-		),
-		mcp.WithString("name",
-			mcp.Description("OpenStackVersion CR name (optional, auto-discovers if not provided)"),
-		),
-	)
-
-	s.AddTool(getOpenStackVersionTool, handlers.GetOpenStackVersionHandler(k8sClient))
-
-	// Register the update_openstack_version tool
-	updateOpenStackVersionTool := mcp.NewTool("update_openstack_version",
-		mcp.WithDescription("Update the targetVersion of the first OpenStackVersion CR in the namespace."),
-		mcp.WithString("namespace",
-			mcp.Description("Namespace (default: openstack)"),
-		),
-		mcp.WithString("targetVersion",
-			mcp.Required(),
-			mcp.Description("Target version to set (e.g., '0.0.2')"),
-		),
-	)
-
-	s.AddTool(updateOpenStackVersionTool, handlers.UpdateOpenStackVersionHandler(k8sClient))
-
-	// Register the wait_openstack_version tool
-	waitOpenStackVersionTool := mcp.NewTool("wait_openstack_version",
-		mcp.WithDescription("Wait for a condition on OpenStackVersion CR to become True. Common conditions: MinorUpdateReady, Ready, DeploymentReady, Available."),
-		mcp.WithString("namespace",
-			mcp.Description("Namespace (default: openstack)"),
-		),
-		mcp.WithString("name",
-			mcp.Description("OpenStackVersion CR name (optional, auto-discovers if not provided)"),
-		),
-		mcp.WithString("condition",
-			mcp.Required(),
-			mcp.Description("Condition type to wait for (e.g., 'MinorUpdateReady', 'Ready')"),
-		),
-		mcp.WithNumber("timeout",
-			mcp.Description("Timeout in seconds (default: 300)"),
-		),
-		mcp.WithNumber("pollInterval",
-			mcp.Description("Poll interval in seconds (default: 5)"),
-		),
-	)
-
-	s.AddTool(waitOpenStackVersionTool, handlers.WaitOpenStackVersionHandler(k8sClient))
-
-	// Register the get_openstack_controlplane tool
-	getOpenStackControlPlaneTool := mcp.NewTool("get_openstack_controlplane",
-		mcp.WithDescription("Get OpenStackControlPlane spec and status including service configurations and conditions."),
-		mcp.WithString("namespace",
-			mcp.Description("Namespace (default: openstack)"),
-		),
-		mcp.WithString("name",
-			mcp.Description("OpenStackControlPlane CR name (optional, auto-discovers if not provided)"),
-		),
-	)
-
-	s.AddTool(getOpenStackControlPlaneTool, handlers.GetOpenStackControlPlaneHandler(k8sClient))
-
-	// Register the verify_openstack_controlplane tool
-	verifyOpenStackControlPlaneTool := mcp.NewTool("verify_openstack_controlplane",
-		mcp.WithDescription("Verify all conditions on OpenStackControlPlane CR are ready. Returns allReady status and lists of ready/not-ready conditions."),
-		mcp.WithString("namespace",
-			mcp.Description("Namespace (default: openstack)"),
-		),
-		mcp.WithString("name",
-			mcp.Description("OpenStackControlPlane CR name (optional, auto-discovers if not provided)"),
-		),
-	)
-
-	s.AddTool(verifyOpenStackControlPlaneTool, handlers.VerifyOpenStackControlPlaneHandler(k8sClient))
-
-	// Register the create_dataplane_deployment tool
-	createDataplaneDeploymentTool := mcp.NewTool("create_dataplane_deployment",
-		mcp.WithDescription("Create OpenStackDataplaneDeployment CR. Requires nodeSets array. Optional: servicesOverride, ansibleTags, ansibleLimit."),
-		mcp.WithString("namespace",
-			mcp.Description("Namespace (default: openstack)"),
-		),
-		mcp.WithString("name",
-			mcp.Required(),
-			mcp.Description("Deployment CR name (use dashes/underscores, not dots)"),
-		),
-	)
-
-	s.AddTool(createDataplaneDeploymentTool, handlers.CreateDataplaneDeploymentHandler(k8sClient))
-
-	// Register the create_dataplane_deployment_ovn tool
-	createDataplaneDeploymentOVNTool := mcp.NewTool("create_dataplane_deployment_ovn",
-		mcp.WithDescription("Create OpenStackDataplaneDeployment CR with servicesOverride=['ovn']. Auto-discovers all nodeSets."),
-		mcp.WithString("namespace",
-			mcp.Description("Namespace (default: openstack)"),
-		),
-		mcp.WithString("name",
-			mcp.Required(),
-			mcp.Description("Deployment CR name (use dashes/underscores, not dots)"),
-		),
-	)
-
-	s.AddTool(createDataplaneDeploymentOVNTool, handlers.CreateDataplaneDeploymentOVNHandler(k8sClient))
-
-	// Register the create_dataplane_deployment_update tool
-	createDataplaneDeploymentUpdateTool := mcp.NewTool("create_dataplane_deployment_update",
-		mcp.WithDescription("Create OpenStackDataplaneDeployment CR with servicesOverride=['update']. Auto-discovers all nodeSets."),
-		mcp.WithString("namespace",
-			mcp.Description("Namespace (default: openstack)"),
-		),
-		mcp.WithString("name",
-			mcp.Required(),
-			mcp.Description("Deployment CR name (use dashes/underscores, not dots)"),
-		),
-	)
-
-	s.AddTool(createDataplaneDeploymentUpdateTool, handlers.CreateDataplaneDeploymentUpdateHandler(k8sClient))
-
-	// Register the get_dataplane_deployment tool
-	getDataplaneDeploymentTool := mcp.NewTool("get_dataplane_deployment",
-		mcp.WithDescription("Get OpenStackDataplaneDeployment spec and status including nodeSets, conditions, and deployment statuses."),
-		mcp.WithString("namespace",
-			mcp.Description("Namespace (default: openstack)"),
-		),
-		mcp.WithString("name",
-			mcp.Required(),
-			mcp.Description("Deployment CR name"),
-		),
-	)
-
-	s.AddTool(getDataplaneDeploymentTool, handlers.GetDataplaneDeploymentHandler(k8sClient))
+	ops := operations.NewManager(time.Duration(cfg.Operations.Retention) * time.Second)
 
-	// Register the list_dataplane_deployments tool
-	listDataplaneDeploymentsTool := mcp.NewTool("list_dataplane_deployments",
-		mcp.WithDescription("List all OpenStackDataplaneDeployment CRs in namespace."),
-		mcp.WithString("namespace",
-			mcp.Description("Namespace (default: openstack)"),
-		),
-	)
-
-	s.AddTool(listDataplaneDeploymentsTool, handlers.ListDataplaneDeploymentsHandler(k8sClient))
+	if err := registerTools(s, registry, ops, cfg, settings, *readOnly); err != nil {
+		log.Fatalf("Invalid config: %v", err)
+	}
 
-	// Register the list_dataplane_nodesets tool
-	listDataplaneNodeSetsTool := mcp.NewTool("list_dataplane_nodesets",
-		mcp.WithDescription("List all OpenStackDataplaneNodeSet CRs in namespace."),
-		mcp.WithString("namespace",
-			mcp.Description("Namespace (default: openstack)"),
-		),
-	)
+	registerResources(s, registry, cfg)
+	registerPrompts(s, cfg, settings)
 
-	s.AddTool(listDataplaneNodeSetsTool, handlers.ListDataplaneNodeSetsHandler(k8sClient))
+	// Start the server
+	if *transport == transportHTTP {
+		err = serveHTTP(s, *listenAddr, *endpointPath)
+	} else {
+		err = server.ServeStdio(s)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Server error: %v\n", err)
+		os.Exit(1)
+	}
+}
 
-	// Register the verify_openstack_dataplanenodesets tool
-	verifyDataplaneNodeSetsTool := mcp.NewTool("verify_openstack_dataplanenodesets",
-		mcp.WithDescription("Verify all conditions on all OpenStackDataplaneNodeSet CRs are ready. Returns allReady status and lists of ready/not-ready NodeSets."),
-		mcp.WithString("namespace",
-			mcp.Description("Namespace (default: openstack)"),
-		),
-	)
+// registerSubscriptionHooks starts and stops resource watches as clients
+// subscribe, unsubscribe and disconnect
+func registerSubscriptionHooks(hooks *server.Hooks, subscriptions *handlers.ResourceSubscriptions) {
+	// Reject an invalid URI or unknown cluster with a JSON-RPC error instead
+	// of acknowledging a subscription that would never be notified
+	hooks.AddOnRequestInitialization(func(ctx context.Context, id any, message any) error {
+		raw, ok := message.(json.RawMessage)
+		if !ok {
+			return nil
+		}
+		var request mcp.SubscribeRequest
+		if err := json.Unmarshal(raw, &request); err != nil || request.Method != string(mcp.MethodResourcesSubscribe) {
+			return nil
+		}
+		return subscriptions.Validate(request.Params.URI)
+	})
+
+	hooks.AddAfterSubscribe(func(ctx context.Context, id any, message *mcp.SubscribeRequest, result *mcp.EmptyResult) {
+		session := server.ClientSessionFromContext(ctx)
+		if session == nil {
+			return
+		}
+		if err := subscriptions.Subscribe(session.SessionID(), message.Params.URI); err != nil {
+			log.Printf("Failed to subscribe to resource '%s': %v", message.Params.URI, err)
+		}
+	})
+
+	hooks.AddAfterUnsubscribe(func(ctx context.Context, id any, message *mcp.UnsubscribeRequest, result *mcp.EmptyResult) {
+		session := server.ClientSessionFromContext(ctx)
+		if session == nil {
+			return
+		}
+		subscriptions.Unsubscribe(session.SessionID(), message.Params.URI)
+	})
+
+	hooks.AddOnUnregisterSession(func(ctx context.Context, session server.ClientSession) {
+		subscriptions.UnsubscribeSession(session.SessionID())
+	})
+}
 
-	s.AddTool(verifyDataplaneNodeSetsTool, handlers.VerifyDataplaneNodeSetsHandler(k8sClient))
-
-	// Register the get_resume_step tool
-	getResumeStepTool := mcp.NewTool("get_resume_step",
-		mcp.WithDescription("Determine which upgrade step to resume from based on current state. Analyzes targetVersion, availableVersion, and notReadyConditions to calculate the exact step number. Returns resumeStep (2-10) and explanation."),
-		mcp.WithString("namespace",
-			mcp.Description("Namespace (default: openstack)"),
-		),
-		mcp.WithString("name",
-			mcp.Description("OpenStackVersion CR name (optional, auto-discovers if not provided)"),
-		),
+// serveHTTP serves the MCP server over streamable HTTP on addr at path until
+// SIGINT or SIGTERM is received.
+func serveHTTP(s *server.MCPServer, addr, path string) error {
+	httpServer := server.NewStreamableHTTPServer(s,
+		server.WithEndpointPath(path),
 	)
 
-	s.AddTool(getResumeStepTool, handlers.GetResumeStepHandler(k8sClient))
-
-	// Start the server
-	if err := server.ServeStdio(s); err != nil {
-		fmt.Fprintf(os.Stderr, "Server error: %v\n", err)
-		os.Exit(1)
+	errCh := make(chan error, 1)
+	go func() {
+		log.Printf("Serving MCP streamable HTTP on %s%s", addr, path)
+		errCh <- httpServer.Start(addr)
+	}()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+
+	select {
+	case err := <-errCh:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	case <-sigCh:
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		return httpServer.Shutdown(ctx)
 	}
 }
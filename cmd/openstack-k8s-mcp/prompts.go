@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/dprince/openstack-k8s-mcp/internal/config"
+	"github.com/dprince/openstack-k8s-mcp/internal/handlers"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// registerPrompts publishes the minor update runbook as MCP prompts: the full
+// runbook plus one prompt per step, numbered as returned by get_resume_step
+func registerPrompts(s *server.MCPServer, cfg *config.Config, settings handlers.Settings) {
+	arguments := []mcp.PromptOption{
+		mcp.WithArgument("namespace",
+			mcp.ArgumentDescription(fmt.Sprintf("Namespace (default: %s)", cfg.DefaultNamespace)),
+		),
+		mcp.WithArgument("targetVersion",
+			mcp.ArgumentDescription("Version to update to (default: the availableVersion of the OpenStackVersion CR)"),
+		),
+		mcp.WithArgument("cluster",
+			mcp.ArgumentDescription(fmt.Sprintf("Cluster name from list_clusters (default: %s)", cfg.DefaultClusterName())),
+		),
+	}
+
+	s.AddPrompt(
+		mcp.NewPrompt(handlers.RunbookPromptName, append([]mcp.PromptOption{
+			mcp.WithPromptDescription("Full OpenStack minor update runbook, from determining the resume point to verifying the completed update."),
+		}, arguments...)...),
+		handlers.RunbookPromptHandler(settings),
+	)
+
+	for _, step := range handlers.RunbookSteps {
+		s.AddPrompt(
+			mcp.NewPrompt(handlers.StepPromptName(step.Number), append([]mcp.PromptOption{
+				mcp.WithPromptDescription(fmt.Sprintf("Step %d: %s. %s", step.Number, step.Title, step.Description)),
+			}, arguments...)...),
+			handlers.StepPromptHandler(step, settings),
+		)
+	}
+}
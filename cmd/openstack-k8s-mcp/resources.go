@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dprince/openstack-k8s-mcp/internal/client"
+	"github.com/dprince/openstack-k8s-mcp/internal/config"
+	"github.com/dprince/openstack-k8s-mcp/internal/handlers"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// resourceKindDescriptions describes the CR behind each resource kind
+var resourceKindDescriptions = map[string]string{
+	handlers.ResourceKindVersion:      "OpenStackVersion CR including target, available and deployed versions and conditions.",
+	handlers.ResourceKindControlPlane: "OpenStackControlPlane CR including service configurations and conditions.",
+	handlers.ResourceKindNodeSet:      "OpenStackDataplaneNodeSet CR including nodes, services and conditions.",
+	handlers.ResourceKindDeployment:   "OpenStackDataplaneDeployment CR including nodeSets, conditions and deployment statuses.",
+}
+
+// registerResources publishes the OpenStack CRs as MCP resources. Every URI
+// accepts an optional ?cluster= query selecting a cluster from list_clusters.
+func registerResources(s *server.MCPServer, registry *client.Registry, cfg *config.Config) {
+	// Register the namespace index template and the default namespace index
+	s.AddResourceTemplate(
+		mcp.NewResourceTemplate("openstack://{namespace}{?cluster}", "OpenStack resources in namespace",
+			mcp.WithTemplateDescription("Index of the resource URIs of every OpenStack CR in the namespace."),
+			mcp.WithTemplateMIMEType("application/json"),
+		),
+		handlers.NamespaceResourceHandler(registry),
+	)
+
+	s.AddResource(
+		mcp.NewResource(fmt.Sprintf("openstack://%s", cfg.DefaultNamespace), fmt.Sprintf("OpenStack resources in namespace %s", cfg.DefaultNamespace),
+			mcp.WithResourceDescription("Index of the resource URIs of every OpenStack CR in the default namespace."),
+			mcp.WithMIMEType("application/json"),
+		),
+		func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+			request.Params.Arguments = map[string]any{"namespace": cfg.DefaultNamespace}
+			return handlers.NamespaceResourceHandler(registry)(ctx, request)
+		},
+	)
+
+	// Register one template per CR kind
+	for _, kind := range handlers.ResourceKinds {
+		s.AddResourceTemplate(
+			mcp.NewResourceTemplate(fmt.Sprintf("openstack://{namespace}/%s/{name}{?cluster}", kind), fmt.Sprintf("OpenStack %s", kind),
+				mcp.WithTemplateDescription(resourceKindDescriptions[kind]),
+				mcp.WithTemplateMIMEType("application/json"),
+			),
+			handlers.OpenStackResourceHandler(registry, kind),
+		)
+	}
+}
@@ -0,0 +1,540 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dprince/openstack-k8s-mcp/internal/client"
+	"github.com/dprince/openstack-k8s-mcp/internal/config"
+	"github.com/dprince/openstack-k8s-mcp/internal/handlers"
+	"github.com/dprince/openstack-k8s-mcp/internal/operations"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// mutatingTools lists the tools that change cluster state. They are not
+// registered when the server runs in read-only mode.
+var mutatingTools = map[string]bool{
+	"update_openstack_version":           true,
+	"create_dataplane_deployment":        true,
+	"create_dataplane_deployment_ovn":    true,
+	"create_dataplane_deployment_update": true,
+	"run_minor_update":                   true,
+}
+
+// asyncTools lists the long-running tools that accept the async argument to
+// run in the background as an operation
+var asyncTools = map[string]bool{
+	"wait_openstack_version":      true,
+	"wait_openstack_controlplane": true,
+	"wait_dataplane_nodesets":     true,
+	"wait_dataplane_deployment":   true,
+	"run_minor_update":            true,
+}
+
+// registerTools registers every MCP tool on the server. It is shared by all
+// transports so stdio and HTTP clients see the same tool registry.
+// Tools disabled by the config allow/deny lists are skipped, and an error is
+// returned if those lists name a tool that does not exist.
+func registerTools(s *server.MCPServer, registry *client.Registry, ops *operations.Manager, cfg *config.Config, settings handlers.Settings, readOnly bool) error {
+	knownTools := map[string]bool{}
+	addTool := func(tool mcp.Tool, handler server.ToolHandlerFunc) {
+		knownTools[tool.Name] = true
+		if readOnly && mutatingTools[tool.Name] {
+			return
+		}
+		if !cfg.ToolEnabled(tool.Name) {
+			return
+		}
+		s.AddTool(tool, handler)
+	}
+
+	// addClusterTool adds the optional cluster argument to the tool and
+	// dispatches each call to the handler built for that cluster. Long-running
+	// tools also get the async argument.
+	clusterDescription := fmt.Sprintf("Cluster name from list_clusters (default: %s)", registry.Default())
+	addClusterTool := func(tool mcp.Tool, newHandler func(k8sClient *client.K8sClient, settings handlers.Settings) handlers.HandlerFunc) {
+		mcp.WithString("cluster", mcp.Description(clusterDescription))(&tool)
+		handler := handlers.ClusterHandler(registry, settings, newHandler)
+		if asyncTools[tool.Name] {
+			mcp.WithBoolean("async",
+				mcp.Description("Run in the background and return an operationId immediately; poll get_operation for the result (default: false)"),
+			)(&tool)
+			handler = handlers.AsyncHandler(ops, tool.Name, handler)
+		}
+		addTool(tool, handler)
+	}
+
+	namespaceDescription := fmt.Sprintf("Namespace (default: %s)", cfg.DefaultNamespace)
+	timeoutDescription := fmt.Sprintf("Timeout in seconds (default: %d)", cfg.Wait.Timeout)
+	pollIntervalDescription := fmt.Sprintf("Interval in seconds between status updates while waiting (default: %d)", cfg.Wait.PollInterval)
+	waitConditionDescription := fmt.Sprintf("Condition to wait for: 'Type' to wait for True, or 'Type=False', 'Type=Unknown', 'Type=Absent' (default: '%s')", handlers.DefaultWaitCondition)
+
+	// addWaitArguments adds the multi-condition and fail-fast arguments shared by the wait tools
+	addWaitArguments := func(tool *mcp.Tool, failReasonsDescription string) {
+		mcp.WithArray("conditions",
+			mcp.Description("Additional conditions to wait for, in the same 'Type' or 'Type=Status' form as condition"),
+			mcp.WithStringItems(),
+		)(tool)
+		mcp.WithString("mode",
+			mcp.Description("Whether 'all' conditions or 'any' condition must match (default: 'all')"),
+			mcp.Enum(string(client.WaitAll), string(client.WaitAny)),
+		)(tool)
+		mcp.WithArray("failReasons",
+			mcp.Description(failReasonsDescription),
+			mcp.WithStringItems(),
+		)(tool)
+	}
+	failReasonsDescription := "Condition reasons that stop the wait as failed as soon as a condition that is not True reports them (default: none)"
+
+	// Register the get_openstack_version tool
+	getOpenStackVersionTool := mcp.NewTool("get_openstack_version",
+		mcp.WithDescription("Get OpenStack version information including targetVersion, availableVersion, deployedVersion, and conditions. With includeImages=true, also returns the deployed and target container images per service, flagging images overridden in customContainerImages."),
+		mcp.WithString("namespace",
+			mcp.Description(namespaceDescription),
+		),
+		mcp.WithString("name",
+			mcp.Description("OpenStackVersion CR name (optional, auto-discovers if not provided)"),
+		),
+		mcp.WithBoolean("includeImages",
+			mcp.Description("Include the deployed and target container images per service (default: false)"),
+		),
+		mcp.WithString("service",
+			mcp.Description("Only include the images of these services, comma-separated (e.g. 'nova,ovn'). Used with includeImages."),
+		),
+	)
+
+	addClusterTool(getOpenStackVersionTool, handlers.GetOpenStackVersionHandler)
+
+	// Register the update_openstack_version tool
+	updateOpenStackVersionTool := mcp.NewTool("update_openstack_version",
+		mcp.WithDescription("Update the targetVersion of an OpenStackVersion CR. Refuses versions the OpenStackVersion does not know, versions older than deployedVersion, and a new target while another update is in progress, unless force is set. The patch only applies if the CR did not change since it was read; a concurrent edit returns a CONFLICT error. Use dryRun=true to validate the patch on the API server and see the resulting spec changes without persisting them."),
+		mcp.WithString("namespace",
+			mcp.Description(namespaceDescription),
+		),
+		mcp.WithString("name",
+			mcp.Description("OpenStackVersion CR name (optional, auto-discovers if not provided)"),
+		),
+		mcp.WithString("targetVersion",
+			mcp.Required(),
+			mcp.Description("Target version to set (e.g., '0.0.2'). See list_available_openstack_versions."),
+		),
+		mcp.WithBoolean("force",
+			mcp.Description("Set targetVersion even if it is unknown, a downgrade, or another update is in progress (default: false)"),
+		),
+		mcp.WithBoolean("dryRun",
+			mcp.Description("Run the patch server-side without persisting it and return the spec changes (default: false)"),
+		),
+		mcp.WithString("resourceVersion",
+			mcp.Description("Only patch if the CR is still at this resourceVersion, e.g. the one returned by get_openstack_version (default: the resourceVersion read by this call)"),
+		),
+	)
+
+	addClusterTool(updateOpenStackVersionTool, handlers.UpdateOpenStackVersionHandler)
+
+	// Register the list_available_openstack_versions tool
+	listAvailableVersionsTool := mcp.NewTool("list_available_openstack_versions",
+		mcp.WithDescription("List the versions known to the OpenStackVersion CR (availableVersion, deployedVersion, targetVersion and the versions with container image defaults), newest first, and the versions the operator can update to."),
+		mcp.WithString("namespace",
+			mcp.Description(namespaceDescription),
+		),
+		mcp.WithString("name",
+			mcp.Description("OpenStackVersion CR name (optional, auto-discovers if not provided)"),
+		),
+	)
+
+	addClusterTool(listAvailableVersionsTool, handlers.ListAvailableOpenStackVersionsHandler)
+
+	// Register the wait_openstack_version tool
+	waitOpenStackVersionTool := mcp.NewTool("wait_openstack_version",
+		mcp.WithDescription("Wait for conditions on OpenStackVersion CR. Watches the CR and returns as soon as all (or any) conditions match, or a fail reason is reported. Returns the condition that triggered completion or failure. Common conditions: MinorUpdateReady, Ready, DeploymentReady, Available."),
+		mcp.WithString("namespace",
+			mcp.Description(namespaceDescription),
+		),
+		mcp.WithString("name",
+			mcp.Description("OpenStackVersion CR name (optional, auto-discovers if not provided)"),
+		),
+		mcp.WithString("condition",
+			mcp.Description("Condition to wait for (e.g., 'MinorUpdateReady', 'Ready'). 'Type' waits for True; 'Type=False', 'Type=Unknown' and 'Type=Absent' are also accepted. Required unless conditions is given."),
+		),
+		mcp.WithNumber("timeout",
+			mcp.Description(timeoutDescription),
+		),
+		mcp.WithNumber("pollInterval",
+			mcp.Description(pollIntervalDescription),
+		),
+	)
+
+	addWaitArguments(&waitOpenStackVersionTool, failReasonsDescription)
+	addClusterTool(waitOpenStackVersionTool, handlers.WaitOpenStackVersionHandler)
+
+	// Register the get_openstack_controlplane tool
+	getOpenStackControlPlaneTool := mcp.NewTool("get_openstack_controlplane",
+		mcp.WithDescription("Get OpenStackControlPlane spec and status including service configurations and conditions."),
+		mcp.WithString("namespace",
+			mcp.Description(namespaceDescription),
+		),
+		mcp.WithString("name",
+			mcp.Description("OpenStackControlPlane CR name (optional, auto-discovers if not provided)"),
+		),
+	)
+
+	addClusterTool(getOpenStackControlPlaneTool, handlers.GetOpenStackControlPlaneHandler)
+
+	// Register the summarize_openstack_controlplane tool
+	summarizeOpenStackControlPlaneTool := mcp.NewTool("summarize_openstack_controlplane",
+		mcp.WithDescription("Summarize the OpenStackControlPlane as one compact table: a row per service section (dns, keystone, placement, glance, cinder, galera, rabbitmq, memcached, ovn, neutron, nova, ...) with whether it is enabled, its replicas, the container images customized on the OpenStackVersion, and its Ready condition from status. Prefer it over get_openstack_controlplane, which returns the whole spec and status."),
+		mcp.WithString("namespace",
+			mcp.Description(namespaceDescription),
+		),
+		mcp.WithString("name",
+			mcp.Description("OpenStackControlPlane CR name (optional, auto-discovers if not provided)"),
+		),
+	)
+
+	addClusterTool(summarizeOpenStackControlPlaneTool, handlers.SummarizeOpenStackControlPlaneHandler)
+
+	// Register the verify_openstack_controlplane tool
+	verifyOpenStackControlPlaneTool := mcp.NewTool("verify_openstack_controlplane",
+		mcp.WithDescription("Verify all conditions on OpenStackControlPlane CR are ready. Returns allReady status and lists of ready/not-ready conditions."),
+		mcp.WithString("namespace",
+			mcp.Description(namespaceDescription),
+		),
+		mcp.WithString("name",
+			mcp.Description("OpenStackControlPlane CR name (optional, auto-discovers if not provided)"),
+		),
+	)
+
+	addClusterTool(verifyOpenStackControlPlaneTool, handlers.VerifyOpenStackControlPlaneHandler)
+
+	// Register the trace_controlplane_conditions tool
+	traceControlPlaneConditionsTool := mcp.NewTool("trace_controlplane_conditions",
+		mcp.WithDescription("Drill down from the OpenStackControlPlane conditions into the service CRs that carry the detail, e.g. from OpenStackControlPlaneNovaReady=False to the Nova, NovaCell and NovaConductor CRs. Discovers every *.openstack.org API group, maps each condition to the CRs of its service owned by the control plane, and follows ownerReferences down. Returns a tree per condition that keeps only the failing CRs, and the rootCauses: the deepest failing CRs with their path and failing conditions."),
+		mcp.WithString("namespace",
+			mcp.Description(namespaceDescription),
+		),
+		mcp.WithString("name",
+			mcp.Description("OpenStackControlPlane CR name (optional, auto-discovers if not provided)"),
+		),
+		mcp.WithString("condition",
+			mcp.Description("Controlplane condition to trace, e.g. 'OpenStackControlPlaneNovaReady', even if it is True (default: every condition that is not True, except Ready)"),
+		),
+	)
+
+	addClusterTool(traceControlPlaneConditionsTool, handlers.TraceControlPlaneConditionsHandler)
+
+	// Register the diagnose_not_ready tool
+	diagnoseNotReadyTool := mcp.NewTool("diagnose_not_ready",
+		mcp.WithDescription("Walk from the not-ready OpenStackControlPlane conditions to the failing pods. Traces the conditions to the deepest failing OpenStack CRs like trace_controlplane_conditions, then follows ownerReferences from each of them to its Deployments, StatefulSets and Jobs and their pods. Reports restart counts, waiting/terminated reasons and the last log lines of failing containers. Everything is looked up in the given namespace only."),
+		mcp.WithString("namespace",
+			mcp.Description(namespaceDescription),
+		),
+		mcp.WithString("name",
+			mcp.Description("OpenStackControlPlane CR name (optional, auto-discovers if not provided)"),
+		),
+		mcp.WithString("condition",
+			mcp.Description("Controlplane condition to diagnose, e.g. 'OpenStackControlPlaneNovaReady' (default: every condition that is not True, except Ready)"),
+		),
+		mcp.WithNumber("tailLines",
+			mcp.Description("Number of log lines returned per failing container (default: 20, 0 disables logs)"),
+		),
+	)
+
+	addClusterTool(diagnoseNotReadyTool, handlers.DiagnoseNotReadyHandler)
+
+	// Register the wait_openstack_controlplane tool
+	waitOpenStackControlPlaneTool := mcp.NewTool("wait_openstack_controlplane",
+		mcp.WithDescription("Wait for conditions on OpenStackControlPlane CR (default: Ready). Watches the CR and returns as soon as all (or any) conditions match, or a fail reason is reported. Returns the condition that triggered completion or failure."),
+		mcp.WithString("namespace",
+			mcp.Description(namespaceDescription),
+		),
+		mcp.WithString("name",
+			mcp.Description("OpenStackControlPlane CR name (optional, auto-discovers if not provided)"),
+		),
+		mcp.WithString("condition",
+			mcp.Description(waitConditionDescription),
+		),
+		mcp.WithNumber("timeout",
+			mcp.Description(timeoutDescription),
+		),
+		mcp.WithNumber("pollInterval",
+			mcp.Description(pollIntervalDescription),
+		),
+	)
+
+	addWaitArguments(&waitOpenStackControlPlaneTool, failReasonsDescription)
+	addClusterTool(waitOpenStackControlPlaneTool, handlers.WaitOpenStackControlPlaneHandler)
+
+	// Register the create_dataplane_deployment tool
+	createDataplaneDeploymentTool := mcp.NewTool("create_dataplane_deployment",
+		mcp.WithDescription("Create OpenStackDataplaneDeployment CR. Requires nodeSets array. Optional: servicesOverride, ansibleTags, ansibleLimit."),
+		mcp.WithString("namespace",
+			mcp.Description(namespaceDescription),
+		),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("Deployment CR name (use dashes/underscores, not dots)"),
+		),
+	)
+
+	addClusterTool(createDataplaneDeploymentTool, handlers.CreateDataplaneDeploymentHandler)
+
+	// Register the create_dataplane_deployment_ovn tool
+	createDataplaneDeploymentOVNTool := mcp.NewTool("create_dataplane_deployment_ovn",
+		mcp.WithDescription("Create OpenStackDataplaneDeployment CR with servicesOverride=['ovn']. Auto-discovers all nodeSets."),
+		mcp.WithString("namespace",
+			mcp.Description(namespaceDescription),
+		),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("Deployment CR name (use dashes/underscores, not dots)"),
+		),
+	)
+
+	addClusterTool(createDataplaneDeploymentOVNTool, handlers.CreateDataplaneDeploymentOVNHandler)
+
+	// Register the create_dataplane_deployment_update tool
+	createDataplaneDeploymentUpdateTool := mcp.NewTool("create_dataplane_deployment_update",
+		mcp.WithDescription("Create OpenStackDataplaneDeployment CR with servicesOverride=['update']. Auto-discovers all nodeSets."),
+		mcp.WithString("namespace",
+			mcp.Description(namespaceDescription),
+		),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("Deployment CR name (use dashes/underscores, not dots)"),
+		),
+	)
+
+	addClusterTool(createDataplaneDeploymentUpdateTool, handlers.CreateDataplaneDeploymentUpdateHandler)
+
+	// Register the get_dataplane_deployment tool
+	getDataplaneDeploymentTool := mcp.NewTool("get_dataplane_deployment",
+		mcp.WithDescription("Get OpenStackDataplaneDeployment spec and status including nodeSets, conditions, and deployment statuses."),
+		mcp.WithString("namespace",
+			mcp.Description(namespaceDescription),
+		),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("Deployment CR name"),
+		),
+	)
+
+	addClusterTool(getDataplaneDeploymentTool, handlers.GetDataplaneDeploymentHandler)
+
+	// Register the wait_dataplane_deployment tool
+	waitDataplaneDeploymentTool := mcp.NewTool("wait_dataplane_deployment",
+		mcp.WithDescription("Wait for conditions on an OpenStackDataplaneDeployment CR (default: Ready), e.g. for the deployment to finish. Watches the CR and returns as soon as all (or any) conditions match, and fails fast when the deployment reports a failure reason. Returns the condition that triggered completion or failure."),
+		mcp.WithString("namespace",
+			mcp.Description(namespaceDescription),
+		),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("Deployment CR name"),
+		),
+		mcp.WithString("condition",
+			mcp.Description(waitConditionDescription),
+		),
+		mcp.WithNumber("timeout",
+			mcp.Description(timeoutDescription),
+		),
+		mcp.WithNumber("pollInterval",
+			mcp.Description(pollIntervalDescription),
+		),
+	)
+
+	addWaitArguments(&waitDataplaneDeploymentTool, fmt.Sprintf("Condition reasons that stop the wait as failed as soon as a condition that is not True reports them (default: %s; pass [] to disable)", strings.Join(handlers.DeploymentFailReasons, ", ")))
+	addClusterTool(waitDataplaneDeploymentTool, handlers.WaitDataplaneDeploymentHandler)
+
+	// Register the list_dataplane_deployments tool
+	listDataplaneDeploymentsTool := mcp.NewTool("list_dataplane_deployments",
+		mcp.WithDescription("List all OpenStackDataplaneDeployment CRs in namespace."),
+		mcp.WithString("namespace",
+			mcp.Description(namespaceDescription),
+		),
+	)
+
+	addClusterTool(listDataplaneDeploymentsTool, handlers.ListDataplaneDeploymentsHandler)
+
+	// Register the list_dataplane_nodesets tool
+	listDataplaneNodeSetsTool := mcp.NewTool("list_dataplane_nodesets",
+		mcp.WithDescription("List all OpenStackDataplaneNodeSet CRs in namespace."),
+		mcp.WithString("namespace",
+			mcp.Description(namespaceDescription),
+		),
+	)
+
+	addClusterTool(listDataplaneNodeSetsTool, handlers.ListDataplaneNodeSetsHandler)
+
+	// Register the verify_openstack_dataplanenodesets tool
+	verifyDataplaneNodeSetsTool := mcp.NewTool("verify_openstack_dataplanenodesets",
+		mcp.WithDescription("Verify all conditions on all OpenStackDataplaneNodeSet CRs are ready. Returns allReady status and lists of ready/not-ready NodeSets."),
+		mcp.WithString("namespace",
+			mcp.Description(namespaceDescription),
+		),
+	)
+
+	addClusterTool(verifyDataplaneNodeSetsTool, handlers.VerifyDataplaneNodeSetsHandler)
+
+	// Register the wait_dataplane_nodesets tool
+	waitDataplaneNodeSetsTool := mcp.NewTool("wait_dataplane_nodesets",
+		mcp.WithDescription("Wait for conditions (default: Ready) on every OpenStackDataplaneNodeSet CR in namespace, or on a single NodeSet. Watches the CRs and returns as soon as all (or any) conditions match on every NodeSet, or a fail reason is reported. Returns the conditions of each NodeSet and the condition that triggered completion or failure."),
+		mcp.WithString("namespace",
+			mcp.Description(namespaceDescription),
+		),
+		mcp.WithString("name",
+			mcp.Description("OpenStackDataplaneNodeSet CR name (optional, waits on every NodeSet if not provided)"),
+		),
+		mcp.WithString("condition",
+			mcp.Description(waitConditionDescription),
+		),
+		mcp.WithNumber("timeout",
+			mcp.Description(timeoutDescription),
+		),
+		mcp.WithNumber("pollInterval",
+			mcp.Description(pollIntervalDescription),
+		),
+	)
+
+	addWaitArguments(&waitDataplaneNodeSetsTool, failReasonsDescription)
+	addClusterTool(waitDataplaneNodeSetsTool, handlers.WaitDataplaneNodeSetsHandler)
+
+	// Register the detect_stuck_conditions tool
+	detectStuckConditionsTool := mcp.NewTool("detect_stuck_conditions",
+		mcp.WithDescription("Find conditions that have not been True for longer than a threshold, using their lastTransitionTime, and CRs whose status.observedGeneration has lagged behind metadata.generation for longer than the threshold, across the OpenStackVersion, OpenStackControlPlane, OpenStackDataplaneNodeSet and OpenStackDataplaneDeployment CRs of a namespace. Returns each finding with its age, the threshold and the condition message, oldest first. Unlike the verify tools, this tells a condition that just went False from one that has been stuck for hours."),
+		mcp.WithString("namespace",
+			mcp.Description(namespaceDescription),
+		),
+		mcp.WithNumber("thresholdSeconds",
+			mcp.Description("Report conditions not True, and statuses not reconciled, for longer than this many seconds (default: 1800)"),
+		),
+		mcp.WithArray("kinds",
+			mcp.Description("Kinds to check (default: all of OpenStackVersion, OpenStackControlPlane, OpenStackDataplaneNodeSet, OpenStackDataplaneDeployment)"),
+			mcp.WithStringItems(),
+		),
+	)
+
+	addClusterTool(detectStuckConditionsTool, handlers.DetectStuckConditionsHandler)
+
+	// Register the get_resume_step tool
+	getResumeStepTool := mcp.NewTool("get_resume_step",
+		mcp.WithDescription("Determine which upgrade step to resume from based on current state. Uses the update record persisted on the OpenStackVersion CR (current step, deployments created, timestamps, who triggered it), falling back to a versioned decision table over targetVersion, availableVersion, deployedVersion and every OpenStackVersion condition. Returns resumeStep (1-10) and resumeStepName, the matching minor_update_step_N prompt as resumePrompt, source ('record' or 'conditions'), updateRecord, explanation, the matchedRules path and decisionTableVersion. resumeStep 1 means the OpenStackVersion has not reported an availableVersion yet."),
+		mcp.WithString("namespace",
+			mcp.Description(namespaceDescription),
+		),
+		mcp.WithString("name",
+			mcp.Description("OpenStackVersion CR name (optional, auto-discovers if not provided)"),
+		),
+	)
+
+	addClusterTool(getResumeStepTool, handlers.GetResumeStepHandler)
+
+	// Register the validate_pre_upgrade tool
+	validatePreUpgradeTool := mcp.NewTool("validate_pre_upgrade",
+		mcp.WithDescription("Run the pre-upgrade validation (runbook Step 2) in one call: the controlplane is ready, all nodeSets are ready, no OpenStackDataplaneDeployment is still running, targetVersion equals status.availableVersion, and deployedVersion differs from targetVersion. Returns passed and a checklist with a pass/fail reason for each check."),
+		mcp.WithString("namespace",
+			mcp.Description(namespaceDescription),
+		),
+		mcp.WithString("name",
+			mcp.Description("OpenStackVersion CR name (optional, auto-discovers if not provided)"),
+		),
+		mcp.WithString("targetVersion",
+			mcp.Required(),
+			mcp.Description("Version the update would move to"),
+		),
+	)
+
+	addClusterTool(validatePreUpgradeTool, handlers.ValidatePreUpgradeHandler)
+
+	// Register the plan_minor_update tool
+	planMinorUpdateTool := mcp.NewTool("plan_minor_update",
+		mcp.WithDescription("Preview a minor update without changing anything: compares the container images in OpenStackVersion status.containerImages with the images targetVersion would roll out (its defaults in status.containerImageVersionDefaults plus spec.customContainerImages). Returns the image changes per service, the untouched services, and whether a dataplane update (and the OVN dataplane step) will be required. Safe to run at any time."),
+		mcp.WithString("namespace",
+			mcp.Description(namespaceDescription),
+		),
+		mcp.WithString("name",
+			mcp.Description("OpenStackVersion CR name (optional, auto-discovers if not provided)"),
+		),
+		mcp.WithString("targetVersion",
+			mcp.Description("Version to preview (default: status.availableVersion)"),
+		),
+	)
+
+	addClusterTool(planMinorUpdateTool, handlers.PlanMinorUpdateHandler)
+
+	// Register the get_update_history tool
+	getUpdateHistoryTool := mcp.NewTool("get_update_history",
+		mcp.WithDescription("Get the timeline of the last minor update: the previous deployedVersion to targetVersion transition and the OVN controlplane, OVN dataplane, controlplane and dataplane phases in order, each with its start, completion and duration. Times come from the lastTransitionTime of the OpenStackVersion conditions, the creation and Ready times of the OpenStackDataplaneDeployments, and the update record. Use it to see how long an update took or where it is stalled."),
+		mcp.WithString("namespace",
+			mcp.Description(namespaceDescription),
+		),
+		mcp.WithString("name",
+			mcp.Description("OpenStackVersion CR name (optional, auto-discovers if not provided)"),
+		),
+	)
+
+	addClusterTool(getUpdateHistoryTool, handlers.GetUpdateHistoryHandler)
+
+	// Register the run_minor_update tool
+	runMinorUpdateTool := mcp.NewTool("run_minor_update",
+		mcp.WithDescription("Run the minor update runbook steps 2-10 in order: pre-upgrade validation, set targetVersion, wait for MinorUpdateOVNControlplane, deploy and monitor OVN on the dataplane, wait for MinorUpdateControlplane, deploy and monitor the update on the dataplane, and final verification. Resumes from the step get_resume_step computes and stops at the first failing step; call it again after fixing the problem to continue. Returns the outcome of every step. Use async=true, since an update usually takes longer than a client request timeout."),
+		mcp.WithString("namespace",
+			mcp.Description(namespaceDescription),
+		),
+		mcp.WithString("name",
+			mcp.Description("OpenStackVersion CR name (optional, auto-discovers if not provided)"),
+		),
+		mcp.WithString("targetVersion",
+			mcp.Description("Version to update to. Required to start an update; an update in progress continues to its own targetVersion."),
+		),
+		mcp.WithNumber("timeout",
+			mcp.Description(fmt.Sprintf("Timeout in seconds for each wait step (default: %d)", handlers.DefaultMinorUpdateStepTimeout)),
+		),
+		mcp.WithNumber("pollInterval",
+			mcp.Description(pollIntervalDescription),
+		),
+	)
+
+	addClusterTool(runMinorUpdateTool, handlers.RunMinorUpdateHandler)
+
+	// Register the list_clusters tool
+	listClustersTool := mcp.NewTool("list_clusters",
+		mcp.WithDescription("List the clusters this server can query. Pass a cluster name as the 'cluster' argument of any other tool."),
+	)
+
+	addTool(listClustersTool, handlers.ListClustersHandler(registry))
+
+	// Register the get_operation tool
+	getOperationTool := mcp.NewTool("get_operation",
+		mcp.WithDescription("Get the status, latest progress and, once finished, the result of an operation started with async=true."),
+		mcp.WithString("operationId",
+			mcp.Required(),
+			mcp.Description("Operation ID returned when the operation was started"),
+		),
+	)
+
+	addTool(getOperationTool, handlers.GetOperationHandler(ops))
+
+	// Register the list_operations tool
+	listOperationsTool := mcp.NewTool("list_operations",
+		mcp.WithDescription(fmt.Sprintf("List running operations and operations completed in the last %d seconds.", cfg.Operations.Retention)),
+		mcp.WithString("status",
+			mcp.Description("Only list operations with this status"),
+			mcp.Enum(string(operations.StatusRunning), string(operations.StatusCompleted), string(operations.StatusFailed), string(operations.StatusCancelled)),
+		),
+	)
+
+	addTool(listOperationsTool, handlers.ListOperationsHandler(ops))
+
+	// Register the cancel_operation tool
+	cancelOperationTool := mcp.NewTool("cancel_operation",
+		mcp.WithDescription("Cancel a running operation started with async=true."),
+		mcp.WithString("operationId",
+			mcp.Required(),
+			mcp.Description("Operation ID returned when the operation was started"),
+		),
+	)
+
+	addTool(cancelOperationTool, handlers.CancelOperationHandler(ops))
+
+	return cfg.ValidateToolNames(knownTools)
+}
@@ -3,14 +3,17 @@ package client
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"time"
 
 	openstackv1beta1 "github.com/openstack-k8s-operators/openstack-operator/apis/core/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
 	"k8s.io/client-go/dynamic"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 )
@@ -41,14 +44,47 @@ var (
 	}
 )
 
+// ErrReadOnly is returned by mutating calls when the client is in read-only mode
+var ErrReadOnly = errors.New("client is in read-only mode")
+
+// Options configures a K8sClient
+type Options struct {
+	// ReadOnly makes every Patch/Create call fail with ErrReadOnly
+	ReadOnly bool
+	// KubeContext selects a kubeconfig context instead of the current one
+	KubeContext string
+	// Kubeconfig is an explicit kubeconfig file path
+	Kubeconfig string
+	// WaitTimeout is the WaitForCondition timeout in seconds used when none is given.
+	// It is required; the default comes from the server configuration.
+	WaitTimeout int
+	// WaitPollInterval is the WaitForCondition poll interval in seconds used when none is given.
+	// It is required; the default comes from the server configuration.
+	WaitPollInterval int
+}
+
 // K8sClient wraps Kubernetes client functionality
 type K8sClient struct {
-	client dynamic.Interface
+	client           dynamic.Interface
+	discovery        discovery.CachedDiscoveryInterface
+	coreV1           corev1client.CoreV1Interface
+	host             string
+	kubeContext      string
+	readOnly         bool
+	waitTimeout      int
+	waitPollInterval int
 }
 
 // NewK8sClient creates a new Kubernetes client
-func NewK8sClient() (*K8sClient, error) {
-	config, err := getKubeConfig()
+func NewK8sClient(opts Options) (*K8sClient, error) {
+	if opts.WaitTimeout <= 0 {
+		return nil, fmt.Errorf("wait timeout must be greater than 0, got %d", opts.WaitTimeout)
+	}
+	if opts.WaitPollInterval <= 0 {
+		return nil, fmt.Errorf("wait poll interval must be greater than 0, got %d", opts.WaitPollInterval)
+	}
+
+	config, err := getKubeConfig(opts.KubeContext, opts.Kubeconfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get kubeconfig: %w", err)
 	}
@@ -58,20 +94,60 @@ func NewK8sClient() (*K8sClient, error) {
 		return nil, fmt.Errorf("failed to create dynamic client: %w", err)
 	}
 
-	return &K8sClient{client: dynClient}, nil
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create discovery client: %w", err)
+	}
+
+	coreV1Client, err := corev1client.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create core client: %w", err)
+	}
+
+	k8sClient := &K8sClient{
+		client:           dynClient,
+		discovery:        memory.NewMemCacheClient(discoveryClient),
+		coreV1:           coreV1Client,
+		host:             config.Host,
+		kubeContext:      opts.KubeContext,
+		readOnly:         opts.ReadOnly,
+		waitTimeout:      opts.WaitTimeout,
+		waitPollInterval: opts.WaitPollInterval,
+	}
+
+	return k8sClient, nil
+}
+
+// ReadOnly reports whether the client refuses mutating calls
+func (c *K8sClient) ReadOnly() bool {
+	return c.readOnly
+}
+
+// Host returns the API server URL the client talks to
+func (c *K8sClient) Host() string {
+	return c.host
 }
 
-// getKubeConfig attempts to get kubeconfig from in-cluster or kubeconfig file
-func getKubeConfig() (*rest.Config, error) {
+// KubeContext returns the kubeconfig context the client was created from, if any
+func (c *K8sClient) KubeContext() string {
+	return c.kubeContext
+}
+
+// getKubeConfig attempts to get kubeconfig from in-cluster or kubeconfig file.
+// An explicit kubeContext or kubeconfig path always uses the kubeconfig file.
+func getKubeConfig(kubeContext, kubeconfig string) (*rest.Config, error) {
 	// Try in-cluster config first
-	config, err := rest.InClusterConfig()
-	if err == nil {
-		return config, nil
+	if kubeContext == "" && kubeconfig == "" {
+		config, err := rest.InClusterConfig()
+		if err == nil {
+			return config, nil
+		}
 	}
 
 	// Fall back to kubeconfig file
 	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
-	configOverrides := &clientcmd.ConfigOverrides{}
+	loadingRules.ExplicitPath = kubeconfig
+	configOverrides := &clientcmd.ConfigOverrides{CurrentContext: kubeContext}
 	kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, configOverrides)
 	return kubeConfig.ClientConfig()
 }
@@ -85,7 +161,11 @@ func (c *K8sClient) GetOpenStackVersion(ctx context.Context, namespace, name str
 		return nil, fmt.Errorf("failed to get OpenStackVersion: %w", err)
 	}
 
-	// Convert unstructured to OpenStackVersion
+	return openStackVersionFromUnstructured(unstructuredObj)
+}
+
+// openStackVersionFromUnstructured converts an unstructured object to an OpenStackVersion
+func openStackVersionFromUnstructured(unstructuredObj *unstructured.Unstructured) (*openstackv1beta1.OpenStackVersion, error) {
 	data, err := unstructuredObj.MarshalJSON()
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal unstructured object: %w", err)
@@ -153,8 +233,22 @@ func (c *K8sClient) ListOpenStackControlPlanes(ctx context.Context, namespace st
 	return controlPlanes, nil
 }
 
+// PatchOptions controls how an OpenStackVersion CR is patched
+type PatchOptions struct {
+	// ResourceVersion, when set, makes the patch fail with a Conflict error
+	// if the CR changed since it was read at that resourceVersion
+	ResourceVersion string
+	// DryRun runs the patch through admission and validation on the API
+	// server without persisting it
+	DryRun bool
+}
+
 // PatchOpenStackVersion patches the targetVersion and optionally customContainerImages fields of an OpenStackVersion CR
-func (c *K8sClient) PatchOpenStackVersion(ctx context.Context, namespace, name, targetVersion string, customContainerImages map[string]interface{}) (*openstackv1beta1.OpenStackVersion, error) {
+func (c *K8sClient) PatchOpenStackVersion(ctx context.Context, namespace, name, targetVersion string, customContainerImages map[string]interface{}, opts PatchOptions) (*openstackv1beta1.OpenStackVersion, error) {
+	if c.readOnly {
+		return nil, fmt.Errorf("refusing to patch OpenStackVersion '%s/%s': %w", namespace, name, ErrReadOnly)
+	}
+
 	// Build the patch data structure
 	spec := map[string]interface{}{
 		"targetVersion": targetVersion,
@@ -169,6 +263,18 @@ func (c *K8sClient) PatchOpenStackVersion(ctx context.Context, namespace, name,
 		"spec": spec,
 	}
 
+	// A resourceVersion in a merge patch is a precondition checked by the API server
+	if opts.ResourceVersion != "" {
+		patch["metadata"] = map[string]interface{}{
+			"resourceVersion": opts.ResourceVersion,
+		}
+	}
+
+	patchOptions := metav1.PatchOptions{}
+	if opts.DryRun {
+		patchOptions.DryRun = []string{metav1.DryRunAll}
+	}
+
 	// Marshal the patch to JSON
 	patchData, err := json.Marshal(patch)
 	if err != nil {
@@ -178,7 +284,7 @@ func (c *K8sClient) PatchOpenStackVersion(ctx context.Context, namespace, name,
 	// Apply the patch
 	unstructuredObj, err := c.client.Resource(openstackVersionGVR).
 		Namespace(namespace).
-		Patch(ctx, name, "application/merge-patch+json", patchData, metav1.PatchOptions{})
+		Patch(ctx, name, "application/merge-patch+json", patchData, patchOptions)
 	if err != nil {
 		return nil, fmt.Errorf("failed to patch OpenStackVersion: %w", err)
 	}
@@ -197,8 +303,51 @@ func (c *K8sClient) PatchOpenStackVersion(ctx context.Context, namespace, name,
 	return &osVersion, nil
 }
 
+// AnnotateOpenStackVersion sets annotations on an OpenStackVersion CR. An
+// empty value removes the annotation.
+func (c *K8sClient) AnnotateOpenStackVersion(ctx context.Context, namespace, name string, annotations map[string]string) error {
+	if c.readOnly {
+		return fmt.Errorf("refusing to annotate OpenStackVersion '%s/%s': %w", namespace, name, ErrReadOnly)
+	}
+
+	values := make(map[string]interface{}, len(annotations))
+	for key, value := range annotations {
+		if value == "" {
+			values[key] = nil
+		} else {
+			values[key] = value
+		}
+	}
+
+	patch := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": values,
+		},
+	}
+
+	// Marshal the patch to JSON
+	patchData, err := json.Marshal(patch)
+	if err != nil {
+		return fmt.Errorf("failed to marshal patch data: %w", err)
+	}
+
+	// Apply the patch
+	_, err = c.client.Resource(openstackVersionGVR).
+		Namespace(namespace).
+		Patch(ctx, name, "application/merge-patch+json", patchData, metav1.PatchOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to annotate OpenStackVersion: %w", err)
+	}
+
+	return nil
+}
+
 // CreateDataplaneDeployment creates a new OpenStackDataplaneDeployment CR
 func (c *K8sClient) CreateDataplaneDeployment(ctx context.Context, namespace, name string, spec map[string]interface{}) error {
+	if c.readOnly {
+		return fmt.Errorf("refusing to create OpenStackDataplaneDeployment '%s/%s': %w", namespace, name, ErrReadOnly)
+	}
+
 	// Build the deployment object
 	deployment := map[string]interface{}{
 		"apiVersion": "dataplane.openstack.org/v1beta1",
@@ -262,6 +411,18 @@ func (c *K8sClient) ListDataplaneDeployments(ctx context.Context, namespace stri
 	return deployments, nil
 }
 
+// GetDataplaneNodeSet retrieves an OpenStackDataplaneNodeSet CR from the specified namespace
+func (c *K8sClient) GetDataplaneNodeSet(ctx context.Context, namespace, name string) (map[string]interface{}, error) {
+	unstructuredObj, err := c.client.Resource(openstackDataplaneNodeSetGVR).
+		Namespace(namespace).
+		Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get OpenStackDataplaneNodeSet: %w", err)
+	}
+
+	return unstructuredObj.Object, nil
+}
+
 // ListDataplaneNodeSets lists all OpenStackDataplaneNodeSet CRs in the specified namespace
 func (c *K8sClient) ListDataplaneNodeSets(ctx context.Context, namespace string) ([]map[string]interface{}, error) {
 	unstructuredList, err := c.client.Resource(openstackDataplaneNodeSetGVR).
@@ -279,68 +440,35 @@ func (c *K8sClient) ListDataplaneNodeSets(ctx context.Context, namespace string)
 	return nodeSets, nil
 }
 
-// ConditionStatus represents the result of checking a condition
-type ConditionStatus struct {
-	Met     bool
-	Message string
-	Reason  string
-}
-
-// WaitForCondition waits for a specific condition on an OpenStackVersion CR to become true
-// logFunc is called periodically to provide status updates
-func (c *K8sClient) WaitForCondition(ctx context.Context, namespace, name, conditionType string, timeoutSeconds int, pollIntervalSeconds int, logFunc func(string)) (*ConditionStatus, error) {
-	if timeoutSeconds <= 0 {
-		timeoutSeconds = 300 // Default 5 minutes
+// ListObjects lists all CRs of kind in a namespace
+func (c *K8sClient) ListObjects(ctx context.Context, kind Kind, namespace string) ([]map[string]interface{}, error) {
+	gvr, ok := kindGVRs[kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown kind '%s'", kind)
 	}
 
-	if pollIntervalSeconds <= 0 {
-		pollIntervalSeconds = 5 // Default 5 seconds
+	unstructuredList, err := c.client.Resource(gvr).
+		Namespace(namespace).
+		List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", kind, err)
 	}
 
-	pollInterval := pollIntervalSeconds
-	maxAttempts := timeoutSeconds / pollInterval
-
-	logFunc(fmt.Sprintf("Waiting for condition '%s' on OpenStackVersion '%s/%s' (timeout: %ds)", conditionType, namespace, name, timeoutSeconds))
-
-	for attempt := 0; attempt < maxAttempts; attempt++ {
-		osVersion, err := c.GetOpenStackVersion(ctx, namespace, name)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get OpenStackVersion: %w", err)
-		}
+	objects := make([]map[string]interface{}, len(unstructuredList.Items))
+	for i, item := range unstructuredList.Items {
+		objects[i] = item.Object
+	}
 
-		// Check if the condition exists and is true
-		for _, cond := range osVersion.Status.Conditions {
-			if string(cond.Type) == conditionType {
-				if string(cond.Status) == "True" {
-					logFunc(fmt.Sprintf("✓ Condition '%s' is True - Ready!", conditionType))
-					return &ConditionStatus{
-						Met:     true,
-						Message: string(cond.Message),
-						Reason:  string(cond.Reason),
-					}, nil
-				}
-				// Condition exists but is not True - log current status
-				logFunc(fmt.Sprintf("Polling... Condition '%s' status: %s (reason: %s)", conditionType, cond.Status, cond.Reason))
-				break
-			}
-		}
+	return objects, nil
+}
 
-		// Wait before next poll
-		if attempt < maxAttempts-1 {
-			select {
-			case <-ctx.Done():
-				return nil, ctx.Err()
-			case <-time.After(time.Duration(pollInterval) * time.Second):
-				// Continue to next iteration
-			}
-		}
+// WaitForCondition waits for a specific condition on an OpenStackVersion CR to become true.
+// See WaitForResourceConditions.
+func (c *K8sClient) WaitForCondition(ctx context.Context, namespace, name, conditionType string, timeoutSeconds int, pollIntervalSeconds int, progressFunc func(WaitProgress)) (*ConditionStatus, error) {
+	spec := WaitSpec{
+		Conditions: []ConditionExpectation{{Type: conditionType, Status: ConditionTrue}},
 	}
-
-	return &ConditionStatus{
-		Met:     false,
-		Message: fmt.Sprintf("Timeout waiting for condition '%s'", conditionType),
-		Reason:  "Timeout",
-	}, nil
+	return c.WaitForResourceConditions(ctx, KindOpenStackVersion, namespace, name, spec, timeoutSeconds, pollIntervalSeconds, progressFunc)
 }
 
 // VerificationResult represents the result of verifying conditions
@@ -0,0 +1,104 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// openStackGroupSuffix is the suffix of the API groups of the OpenStack operators
+const openStackGroupSuffix = ".openstack.org"
+
+// OpenStackResource is a namespaced resource of an OpenStack operator API group
+type OpenStackResource struct {
+	GVR  schema.GroupVersionResource
+	Kind string
+}
+
+// DiscoverOpenStackResources returns the listable namespaced resources of
+// every *.openstack.org API group in its preferred version, sorted by group and kind.
+// Discovery results are cached; when the cached results fail, they are
+// invalidated and discovery is retried once.
+func (c *K8sClient) DiscoverOpenStackResources() ([]OpenStackResource, error) {
+	if c.discovery == nil {
+		return nil, fmt.Errorf("API discovery is not available")
+	}
+
+	resources, err := c.discoverOpenStackResources()
+	if err != nil {
+		c.discovery.Invalidate()
+		resources, err = c.discoverOpenStackResources()
+	}
+	return resources, err
+}
+
+// InvalidateDiscovery drops the cached discovery results, so the next call to
+// DiscoverOpenStackResources sees operators installed or upgraded since then
+func (c *K8sClient) InvalidateDiscovery() {
+	if c.discovery != nil {
+		c.discovery.Invalidate()
+	}
+}
+
+// discoverOpenStackResources discovers the OpenStack resources through the discovery cache
+func (c *K8sClient) discoverOpenStackResources() ([]OpenStackResource, error) {
+
+	groups, err := c.discovery.ServerGroups()
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover API groups: %w", err)
+	}
+
+	resources := []OpenStackResource{}
+	for _, group := range groups.Groups {
+		if !strings.HasSuffix(group.Name, openStackGroupSuffix) {
+			continue
+		}
+
+		groupVersion := group.PreferredVersion.GroupVersion
+		list, err := c.discovery.ServerResourcesForGroupVersion(groupVersion)
+		if err != nil {
+			return nil, fmt.Errorf("failed to discover resources of %s: %w", groupVersion, err)
+		}
+		gv, err := schema.ParseGroupVersion(groupVersion)
+		if err != nil {
+			return nil, fmt.Errorf("invalid group version '%s': %w", groupVersion, err)
+		}
+
+		for _, resource := range list.APIResources {
+			// Skip subresources such as status
+			if !resource.Namespaced || strings.Contains(resource.Name, "/") || !contains(resource.Verbs, "list") {
+				continue
+			}
+			resources = append(resources, OpenStackResource{GVR: gv.WithResource(resource.Name), Kind: resource.Kind})
+		}
+	}
+
+	sort.Slice(resources, func(i, j int) bool {
+		if resources[i].GVR.Group != resources[j].GVR.Group {
+			return resources[i].GVR.Group < resources[j].GVR.Group
+		}
+		return resources[i].Kind < resources[j].Kind
+	})
+	return resources, nil
+}
+
+// ListResources lists all objects of a resource in a namespace
+func (c *K8sClient) ListResources(ctx context.Context, gvr schema.GroupVersionResource, namespace string) ([]map[string]interface{}, error) {
+	unstructuredList, err := c.client.Resource(gvr).
+		Namespace(namespace).
+		List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", gvr.GroupResource(), err)
+	}
+
+	objects := make([]map[string]interface{}, len(unstructuredList.Items))
+	for i, item := range unstructuredList.Items {
+		objects[i] = item.Object
+	}
+
+	return objects, nil
+}
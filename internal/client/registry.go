@@ -0,0 +1,77 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Cluster names a kubeconfig context served by a Registry
+type Cluster struct {
+	Name        string
+	KubeContext string
+	Kubeconfig  string
+}
+
+// Registry holds one K8sClient per named cluster
+type Registry struct {
+	clients        map[string]*K8sClient
+	names          []string
+	defaultCluster string
+}
+
+// NewRegistry creates a K8sClient for every cluster using opts for the shared
+// settings. defaultCluster is used when no cluster name is given.
+func NewRegistry(clusters []Cluster, defaultCluster string, opts Options) (*Registry, error) {
+	if len(clusters) == 0 {
+		return nil, fmt.Errorf("at least one cluster is required")
+	}
+
+	registry := &Registry{
+		clients:        make(map[string]*K8sClient, len(clusters)),
+		defaultCluster: defaultCluster,
+	}
+
+	for _, cluster := range clusters {
+		clusterOpts := opts
+		clusterOpts.KubeContext = cluster.KubeContext
+		clusterOpts.Kubeconfig = cluster.Kubeconfig
+
+		k8sClient, err := NewK8sClient(clusterOpts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create client for cluster '%s': %w", cluster.Name, err)
+		}
+
+		registry.clients[cluster.Name] = k8sClient
+		registry.names = append(registry.names, cluster.Name)
+	}
+
+	if _, ok := registry.clients[defaultCluster]; !ok {
+		return nil, fmt.Errorf("default cluster '%s' is not registered", defaultCluster)
+	}
+
+	return registry, nil
+}
+
+// Get returns the client for the named cluster, or the default cluster if name is empty
+func (r *Registry) Get(name string) (*K8sClient, error) {
+	if name == "" {
+		name = r.defaultCluster
+	}
+
+	k8sClient, ok := r.clients[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown cluster '%s' (known clusters: %s)", name, strings.Join(r.names, ", "))
+	}
+
+	return k8sClient, nil
+}
+
+// Names returns the registered cluster names in configuration order
+func (r *Registry) Names() []string {
+	return r.names
+}
+
+// Default returns the name of the default cluster
+func (r *Registry) Default() string {
+	return r.defaultCluster
+}
@@ -0,0 +1,544 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// Condition statuses a wait can expect. ConditionAbsent matches a condition
+// that is not reported on the object at all.
+const (
+	ConditionTrue    = "True"
+	ConditionFalse   = "False"
+	ConditionUnknown = "Unknown"
+	ConditionAbsent  = "Absent"
+)
+
+// WaitMode selects whether every condition or any condition of a WaitSpec must match
+type WaitMode string
+
+const (
+	WaitAll WaitMode = "all"
+	WaitAny WaitMode = "any"
+)
+
+// ConditionExpectation is a condition a wait expects to reach a status
+type ConditionExpectation struct {
+	Type   string
+	Status string
+}
+
+// String formats the expectation as Type=Status
+func (e ConditionExpectation) String() string {
+	return fmt.Sprintf("%s=%s", e.Type, e.Status)
+}
+
+// WaitSpec describes when a wait completes or fails
+type WaitSpec struct {
+	// Conditions are the expected conditions, combined according to Mode
+	Conditions []ConditionExpectation
+	// Mode defaults to WaitAll
+	Mode WaitMode
+	// FailReasons stops the wait as soon as any condition that is not True
+	// reports one of these reasons, e.g. a deployment failure
+	FailReasons []string
+}
+
+// ConditionState is the observed state of a condition on an object
+type ConditionState struct {
+	Type    string
+	Found   bool
+	Status  string
+	Reason  string
+	Message string
+}
+
+// Matches reports whether the condition is in the expected status
+func (s ConditionState) Matches(status string) bool {
+	if status == ConditionAbsent {
+		return !s.Found
+	}
+	return s.Found && s.Status == status
+}
+
+// String describes the condition for status updates
+func (s ConditionState) String() string {
+	if !s.Found {
+		return fmt.Sprintf("%s absent", s.Type)
+	}
+	return fmt.Sprintf("%s=%s (reason: %s)", s.Type, s.Status, s.Reason)
+}
+
+// ObjectStatus is the state of the waited-on conditions on a single object
+type ObjectStatus struct {
+	Name string
+	// Conditions holds the state of each expected condition, in WaitSpec order
+	Conditions []ConditionState
+	Met        bool
+	Failed     bool
+	// Trigger is the condition that completed or failed the wait on this object
+	Trigger *ConditionState
+}
+
+// summary describes the expected conditions for status updates
+func (o ObjectStatus) summary() string {
+	states := make([]string, len(o.Conditions))
+	for i, state := range o.Conditions {
+		states[i] = state.String()
+	}
+	return strings.Join(states, ", ")
+}
+
+// ConditionStatus represents the result of checking a condition
+type ConditionStatus struct {
+	Met     bool
+	Failed  bool
+	Message string
+	Reason  string
+	// TriggerObject and Trigger identify the condition that completed or failed the wait
+	TriggerObject string
+	Trigger       *ConditionState
+	// Objects holds the condition of every object waited on, sorted by name
+	Objects []ObjectStatus
+}
+
+// objectConditions reads status.conditions of obj keyed by type, in order
+func objectConditions(obj *unstructured.Unstructured) ([]ConditionState, map[string]ConditionState) {
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+
+	ordered := []ConditionState{}
+	byType := map[string]ConditionState{}
+	for _, c := range conditions {
+		cond, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		state := ConditionState{Found: true}
+		state.Type, _ = cond["type"].(string)
+		state.Status, _ = cond["status"].(string)
+		state.Reason, _ = cond["reason"].(string)
+		state.Message, _ = cond["message"].(string)
+		ordered = append(ordered, state)
+		byType[state.Type] = state
+	}
+
+	return ordered, byType
+}
+
+// evaluate computes the ObjectStatus of obj against spec. previous is the last
+// status of the object, used to find the condition that just changed.
+func (spec WaitSpec) evaluate(obj *unstructured.Unstructured, previous *ObjectStatus) ObjectStatus {
+	ordered, byType := objectConditions(obj)
+
+	status := ObjectStatus{
+		Name:       obj.GetName(),
+		Conditions: make([]ConditionState, len(spec.Conditions)),
+	}
+
+	matched := 0
+	for i, expected := range spec.Conditions {
+		state, ok := byType[expected.Type]
+		if !ok {
+			state = ConditionState{Type: expected.Type}
+		}
+		status.Conditions[i] = state
+		if state.Matches(expected.Status) {
+			matched++
+		}
+	}
+
+	if spec.Mode == WaitAny {
+		status.Met = matched > 0
+	} else {
+		status.Met = matched == len(spec.Conditions)
+	}
+
+	if status.Met {
+		status.Trigger = spec.trigger(status, previous)
+		return status
+	}
+
+	// Fail fast when any condition that is not True reports a terminal reason
+	for _, state := range ordered {
+		if state.Status != ConditionTrue && contains(spec.FailReasons, state.Reason) {
+			state := state
+			status.Failed = true
+			status.Trigger = &state
+			break
+		}
+	}
+
+	return status
+}
+
+// trigger returns the matching condition that completed the wait: the first
+// one that changed since previous, or else the first (any) or last (all) match
+func (spec WaitSpec) trigger(status ObjectStatus, previous *ObjectStatus) *ConditionState {
+	var candidate *ConditionState
+	for i, expected := range spec.Conditions {
+		state := status.Conditions[i]
+		if !state.Matches(expected.Status) {
+			continue
+		}
+		if previous != nil && previous.Conditions[i] != state {
+			return &state
+		}
+		if candidate == nil || spec.Mode != WaitAny {
+			candidate = &state
+		}
+	}
+	return candidate
+}
+
+// WaitProgress is a status update reported while waiting
+type WaitProgress struct {
+	Message string
+	Elapsed time.Duration
+	Timeout time.Duration
+	// Condition is the condition currently waited on, or the one that completed
+	// or failed the wait. It is nil until an object has been observed.
+	Condition *ConditionState
+}
+
+// conditionTracker holds the latest status of every object waited on
+type conditionTracker struct {
+	kind    Kind
+	spec    WaitSpec
+	objects map[string]ObjectStatus
+	// last is the object whose update completed or failed the wait
+	last string
+}
+
+// sorted returns the tracked objects sorted by name
+func (t *conditionTracker) sorted() []ObjectStatus {
+	result := make([]ObjectStatus, 0, len(t.objects))
+	for _, obj := range t.objects {
+		result = append(result, obj)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// met reports whether the conditions match on every tracked object
+func (t *conditionTracker) met() bool {
+	if len(t.objects) == 0 {
+		return false
+	}
+	for _, obj := range t.objects {
+		if !obj.Met {
+			return false
+		}
+	}
+	return true
+}
+
+// failed returns the first tracked object that hit a fail reason
+func (t *conditionTracker) failed() *ObjectStatus {
+	for _, obj := range t.sorted() {
+		if obj.Failed {
+			return &obj
+		}
+	}
+	return nil
+}
+
+// current returns the first expected condition that does not match yet, or the
+// condition that completed or failed the wait
+func (t *conditionTracker) current() *ConditionState {
+	objects := t.sorted()
+	for _, obj := range objects {
+		if obj.Failed {
+			return obj.Trigger
+		}
+	}
+
+	for _, obj := range objects {
+		if obj.Met {
+			continue
+		}
+		for i, expected := range t.spec.Conditions {
+			if !obj.Conditions[i].Matches(expected.Status) {
+				state := obj.Conditions[i]
+				return &state
+			}
+		}
+	}
+
+	if last, ok := t.objects[t.last]; ok {
+		return last.Trigger
+	}
+	return nil
+}
+
+// summary describes the tracked state for status updates
+func (t *conditionTracker) summary() string {
+	objects := t.sorted()
+	switch len(objects) {
+	case 0:
+		return fmt.Sprintf("no %s found", t.kind)
+	case 1:
+		return objects[0].summary()
+	}
+
+	waiting := []string{}
+	for _, obj := range objects {
+		if !obj.Met {
+			waiting = append(waiting, fmt.Sprintf("%s[%s]", obj.Name, obj.summary()))
+		}
+	}
+	return fmt.Sprintf("%d/%d met, waiting on: %s", len(objects)-len(waiting), len(objects), strings.Join(waiting, ", "))
+}
+
+// result builds the ConditionStatus for the tracked state
+func (t *conditionTracker) result() *ConditionStatus {
+	objects := t.sorted()
+	status := &ConditionStatus{
+		Met:     t.met(),
+		Objects: objects,
+	}
+
+	if failed := t.failed(); failed != nil && !status.Met {
+		status.Failed = true
+		status.TriggerObject = failed.Name
+		status.Trigger = failed.Trigger
+		status.Reason = failed.Trigger.Reason
+		status.Message = fmt.Sprintf("Condition '%s' on %s '%s' has terminal reason '%s': %s", failed.Trigger.Type, t.kind, failed.Name, failed.Trigger.Reason, failed.Trigger.Message)
+		return status
+	}
+
+	if !status.Met {
+		return status
+	}
+
+	last := t.objects[t.last]
+	status.TriggerObject = last.Name
+	status.Trigger = last.Trigger
+	if len(objects) == 1 && last.Trigger != nil && last.Trigger.Found {
+		status.Message = last.Trigger.Message
+		status.Reason = last.Trigger.Reason
+	} else if len(objects) == 1 && last.Trigger != nil {
+		status.Message = fmt.Sprintf("Condition '%s' is absent", last.Trigger.Type)
+		status.Reason = "ConditionAbsent"
+	} else {
+		status.Message = fmt.Sprintf("Conditions %s met on all %d %s CRs", t.spec.describe(), len(objects), t.kind)
+		status.Reason = "AllConditionsMet"
+	}
+
+	return status
+}
+
+// describe formats the expected conditions for messages
+func (spec WaitSpec) describe() string {
+	expected := make([]string, len(spec.Conditions))
+	for i, cond := range spec.Conditions {
+		expected[i] = cond.String()
+	}
+	if spec.Mode == WaitAny {
+		return fmt.Sprintf("any of [%s]", strings.Join(expected, ", "))
+	}
+	if len(expected) == 1 {
+		return fmt.Sprintf("'%s'", expected[0])
+	}
+	return fmt.Sprintf("all of [%s]", strings.Join(expected, ", "))
+}
+
+// WaitForResourceConditions waits until the conditions in spec match on CRs of kind.
+// When name is empty it waits for them on every CR of kind in the namespace.
+// It watches the CRs and returns as soon as the conditions match, a fail reason
+// is reported, or the timeout elapses. progressFunc is called when a condition
+// changes and every pollInterval while it is unchanged, to provide status updates.
+func (c *K8sClient) WaitForResourceConditions(ctx context.Context, kind Kind, namespace, name string, spec WaitSpec, timeoutSeconds int, pollIntervalSeconds int, progressFunc func(WaitProgress)) (*ConditionStatus, error) {
+	gvr, ok := kindGVRs[kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown kind '%s'", kind)
+	}
+
+	if len(spec.Conditions) == 0 {
+		return nil, fmt.Errorf("at least one condition is required")
+	}
+
+	if spec.Mode == "" {
+		spec.Mode = WaitAll
+	}
+
+	if timeoutSeconds <= 0 {
+		timeoutSeconds = c.waitTimeout
+	}
+
+	if pollIntervalSeconds <= 0 {
+		pollIntervalSeconds = c.waitPollInterval
+	}
+
+	target := fmt.Sprintf("%s '%s/%s'", kind, namespace, name)
+	if name == "" {
+		target = fmt.Sprintf("all %s CRs in namespace '%s'", kind, namespace)
+	}
+
+	start := time.Now()
+	timeout := time.Duration(timeoutSeconds) * time.Second
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+
+	// The tracker is updated from the watch and read by the status ticker,
+	// and progressFunc is called from both, so guard them with a mutex
+	var mu sync.Mutex
+	tracker := &conditionTracker{
+		kind:    kind,
+		spec:    spec,
+		objects: map[string]ObjectStatus{},
+	}
+	logStatus := func(msg string) {
+		mu.Lock()
+		defer mu.Unlock()
+		progressFunc(WaitProgress{
+			Message:   msg,
+			Elapsed:   time.Since(start),
+			Timeout:   timeout,
+			Condition: tracker.current(),
+		})
+	}
+
+	logStatus(fmt.Sprintf("Waiting for conditions %s on %s (timeout: %ds)", spec.describe(), target, timeoutSeconds))
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(time.Duration(pollIntervalSeconds) * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-waitCtx.Done():
+				return
+			case <-ticker.C:
+				mu.Lock()
+				summary := tracker.summary()
+				mu.Unlock()
+				logStatus(fmt.Sprintf("Waiting... %s", summary))
+			}
+		}
+	}()
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	// update records the status of obj and logs changed conditions
+	update := func(obj *unstructured.Unstructured) {
+		mu.Lock()
+		previous, seen := tracker.objects[obj.GetName()]
+		var previousPtr *ObjectStatus
+		if seen {
+			previousPtr = &previous
+		}
+		status := spec.evaluate(obj, previousPtr)
+		tracker.objects[status.Name] = status
+		tracker.last = status.Name
+		mu.Unlock()
+
+		for i, state := range status.Conditions {
+			if seen && previous.Conditions[i] == state {
+				continue
+			}
+			logStatus(fmt.Sprintf("%s '%s': %s", kind, status.Name, state))
+		}
+
+		if status.Failed {
+			logStatus(fmt.Sprintf("✗ Condition '%s' on %s '%s' has terminal reason '%s'", status.Trigger.Type, kind, status.Name, status.Trigger.Reason))
+		} else if name == "" && status.Met && (!seen || !previous.Met) {
+			logStatus(fmt.Sprintf("✓ Conditions met on %s '%s'", kind, status.Name))
+		}
+	}
+
+	// finished reports whether the conditions match on every object or one failed
+	finished := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return tracker.met() || tracker.failed() != nil
+	}
+
+	err := c.watchObjects(waitCtx, gvr, namespace, name, watchHandler{
+		listed: func(items []unstructured.Unstructured) (bool, error) {
+			if len(items) == 0 {
+				if name != "" {
+					return false, fmt.Errorf("%s '%s/%s' not found", kind, namespace, name)
+				}
+				return false, fmt.Errorf("no %s CRs found in namespace '%s'", kind, namespace)
+			}
+
+			// Drop objects that were deleted while the watch was down
+			mu.Lock()
+			current := map[string]ObjectStatus{}
+			for _, item := range items {
+				if status, ok := tracker.objects[item.GetName()]; ok {
+					current[item.GetName()] = status
+				}
+			}
+			tracker.objects = current
+			mu.Unlock()
+
+			for i := range items {
+				update(&items[i])
+			}
+			return finished(), nil
+		},
+		event: func(eventType watch.EventType, obj *unstructured.Unstructured) (bool, error) {
+			if eventType == watch.Deleted {
+				if name != "" {
+					return false, fmt.Errorf("%s '%s/%s' was deleted while waiting", kind, namespace, name)
+				}
+				mu.Lock()
+				delete(tracker.objects, obj.GetName())
+				mu.Unlock()
+				logStatus(fmt.Sprintf("%s '%s' was deleted", kind, obj.GetName()))
+				return finished(), nil
+			}
+			update(obj)
+			return finished(), nil
+		},
+	})
+
+	mu.Lock()
+	result := tracker.result()
+	summary := tracker.summary()
+	mu.Unlock()
+
+	if err == nil && (result.Met || result.Failed) {
+		if result.Met {
+			logStatus(fmt.Sprintf("✓ Conditions %s met - Ready!", spec.describe()))
+		}
+		return result, nil
+	}
+
+	// The wait deadline expired, as opposed to the caller cancelling ctx
+	if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+		result.Met = false
+		result.Message = fmt.Sprintf("Timeout waiting for conditions %s (last status: %s)", spec.describe(), summary)
+		result.Reason = "Timeout"
+		return result, nil
+	}
+	if err == nil {
+		err = ctx.Err()
+	}
+
+	return nil, err
+}
+
+// contains checks if a string slice contains a specific string
+func contains(slice []string, item string) bool {
+	for _, s := range slice {
+		if s == item {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,199 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// Kind identifies one of the OpenStack CR kinds the client works with
+type Kind string
+
+const (
+	KindOpenStackVersion             Kind = "OpenStackVersion"
+	KindOpenStackControlPlane        Kind = "OpenStackControlPlane"
+	KindOpenStackDataplaneNodeSet    Kind = "OpenStackDataplaneNodeSet"
+	KindOpenStackDataplaneDeployment Kind = "OpenStackDataplaneDeployment"
+)
+
+// kindGVRs maps each Kind to its GroupVersionResource
+var kindGVRs = map[Kind]schema.GroupVersionResource{
+	KindOpenStackVersion:             openstackVersionGVR,
+	KindOpenStackControlPlane:        openstackControlPlaneGVR,
+	KindOpenStackDataplaneNodeSet:    openstackDataplaneNodeSetGVR,
+	KindOpenStackDataplaneDeployment: openstackDataplaneDeploymentGVR,
+}
+
+// Kinds lists every Kind
+var Kinds = []Kind{
+	KindOpenStackVersion,
+	KindOpenStackControlPlane,
+	KindOpenStackDataplaneNodeSet,
+	KindOpenStackDataplaneDeployment,
+}
+
+// watchRetryInterval is the delay before re-establishing a watch after an API error
+const watchRetryInterval = 5 * time.Second
+
+// watchHandler is called by watchObjects with the objects returned by a
+// (re)list and with every watch event afterwards. Returning true stops the watch.
+type watchHandler struct {
+	listed func(items []unstructured.Unstructured) (bool, error)
+	event  func(eventType watch.EventType, obj *unstructured.Unstructured) (bool, error)
+}
+
+// watchObjects lists and then watches the objects of gvr in namespace, restricted to
+// name when it is not empty. Watches resume from the last seen resourceVersion and
+// relist when that resourceVersion has expired. It returns when a handler returns
+// true or an error, when the initial list fails, or when ctx is done. Later API
+// errors are retried every watchRetryInterval.
+func (c *K8sClient) watchObjects(ctx context.Context, gvr schema.GroupVersionResource, namespace, name string, handler watchHandler) error {
+	listOptions := metav1.ListOptions{}
+	if name != "" {
+		listOptions.FieldSelector = fields.OneTermEqualSelector("metadata.name", name).String()
+	}
+
+	resourceInterface := c.client.Resource(gvr).Namespace(namespace)
+	resourceVersion := ""
+	listed := false
+
+	for {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		// (Re)list to get the current state and a resourceVersion to watch from
+		if resourceVersion == "" {
+			list, err := resourceInterface.List(ctx, listOptions)
+			if err != nil {
+				if !listed {
+					return fmt.Errorf("failed to list %s: %w", gvr.Resource, err)
+				}
+				if err := sleepContext(ctx, watchRetryInterval); err != nil {
+					return err
+				}
+				continue
+			}
+			listed = true
+
+			done, err := handler.listed(list.Items)
+			if err != nil || done {
+				return err
+			}
+			resourceVersion = list.GetResourceVersion()
+		}
+
+		watchOptions := listOptions
+		watchOptions.ResourceVersion = resourceVersion
+		watchOptions.AllowWatchBookmarks = true
+
+		watcher, err := resourceInterface.Watch(ctx, watchOptions)
+		if err != nil {
+			if apierrors.IsResourceExpired(err) || apierrors.IsGone(err) {
+				resourceVersion = ""
+				continue
+			}
+			// Transient API error, retry from the same resourceVersion
+			if err := sleepContext(ctx, watchRetryInterval); err != nil {
+				return err
+			}
+			continue
+		}
+
+		var done bool
+		resourceVersion, done, err = consumeWatch(ctx, watcher, resourceVersion, handler)
+		watcher.Stop()
+		if err != nil || done {
+			return err
+		}
+	}
+}
+
+// consumeWatch forwards events from watcher to handler until the result channel
+// closes or the handler is done. It returns the resourceVersion to resume from,
+// which is empty when a relist is required.
+func consumeWatch(ctx context.Context, watcher watch.Interface, resourceVersion string, handler watchHandler) (string, bool, error) {
+	for {
+		select {
+		case <-ctx.Done():
+			return resourceVersion, false, ctx.Err()
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				// Server closed the watch, resume from the last seen resourceVersion
+				return resourceVersion, false, nil
+			}
+
+			switch event.Type {
+			case watch.Error:
+				status := apierrors.FromObject(event.Object)
+				if apierrors.IsResourceExpired(status) || apierrors.IsGone(status) {
+					return "", false, nil
+				}
+				// Transient API error, back off before watching again from
+				// the same resourceVersion
+				if err := sleepContext(ctx, watchRetryInterval); err != nil {
+					return resourceVersion, false, err
+				}
+				return resourceVersion, false, nil
+			case watch.Bookmark:
+				if obj, ok := event.Object.(*unstructured.Unstructured); ok {
+					resourceVersion = obj.GetResourceVersion()
+				}
+			case watch.Added, watch.Modified, watch.Deleted:
+				obj, ok := event.Object.(*unstructured.Unstructured)
+				if !ok {
+					continue
+				}
+				resourceVersion = obj.GetResourceVersion()
+
+				done, err := handler.event(event.Type, obj)
+				if err != nil || done {
+					return resourceVersion, done, err
+				}
+			}
+		}
+	}
+}
+
+// sleepContext waits for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
+
+// WatchObjects calls onChange whenever an object of kind in namespace changes,
+// restricted to name when it is not empty. It blocks until ctx is done.
+// Because changes may be missed while a relist is required, onChange is also
+// called after every relist following an expired resourceVersion.
+func (c *K8sClient) WatchObjects(ctx context.Context, kind Kind, namespace, name string, onChange func()) error {
+	gvr, ok := kindGVRs[kind]
+	if !ok {
+		return fmt.Errorf("unknown kind '%s'", kind)
+	}
+
+	synced := false
+	return c.watchObjects(ctx, gvr, namespace, name, watchHandler{
+		listed: func(items []unstructured.Unstructured) (bool, error) {
+			if synced {
+				onChange()
+			}
+			synced = true
+			return false, nil
+		},
+		event: func(eventType watch.EventType, obj *unstructured.Unstructured) (bool, error) {
+			onChange()
+			return false, nil
+		},
+	})
+}
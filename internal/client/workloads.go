@@ -0,0 +1,102 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var (
+	deploymentGVR = schema.GroupVersionResource{
+		Group:    "apps",
+		Version:  "v1",
+		Resource: "deployments",
+	}
+
+	statefulSetGVR = schema.GroupVersionResource{
+		Group:    "apps",
+		Version:  "v1",
+		Resource: "statefulsets",
+	}
+
+	replicaSetGVR = schema.GroupVersionResource{
+		Group:    "apps",
+		Version:  "v1",
+		Resource: "replicasets",
+	}
+
+	jobGVR = schema.GroupVersionResource{
+		Group:    "batch",
+		Version:  "v1",
+		Resource: "jobs",
+	}
+
+	podGVR = schema.GroupVersionResource{
+		Version:  "v1",
+		Resource: "pods",
+	}
+)
+
+// workloadGVRs are the resources between an OpenStack CR and its pods.
+// Deployments own their pods through ReplicaSets.
+var workloadGVRs = []schema.GroupVersionResource{deploymentGVR, statefulSetGVR, replicaSetGVR, jobGVR}
+
+// ListWorkloads lists the Deployments, StatefulSets, ReplicaSets and Jobs in the specified namespace
+func (c *K8sClient) ListWorkloads(ctx context.Context, namespace string) ([]map[string]interface{}, error) {
+	workloads := []map[string]interface{}{}
+	for _, gvr := range workloadGVRs {
+		objects, err := c.ListResources(ctx, gvr, namespace)
+		if err != nil {
+			return nil, err
+		}
+		workloads = append(workloads, objects...)
+	}
+
+	return workloads, nil
+}
+
+// ListPods lists all pods in the specified namespace
+func (c *K8sClient) ListPods(ctx context.Context, namespace string) ([]corev1.Pod, error) {
+	unstructuredList, err := c.client.Resource(podGVR).
+		Namespace(namespace).
+		List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	// Convert unstructured list to PodList
+	data, err := unstructuredList.MarshalJSON()
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal unstructured list: %w", err)
+	}
+
+	var podList corev1.PodList
+	if err := json.Unmarshal(data, &podList); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal to PodList: %w", err)
+	}
+
+	return podList.Items, nil
+}
+
+// GetPodLogs returns the last tailLines lines of the log of a container.
+// previous returns the log of the last terminated instance of the container.
+func (c *K8sClient) GetPodLogs(ctx context.Context, namespace, name, container string, tailLines int64, previous bool) (string, error) {
+	if c.coreV1 == nil {
+		return "", fmt.Errorf("pod logs are not available")
+	}
+
+	data, err := c.coreV1.Pods(namespace).GetLogs(name, &corev1.PodLogOptions{
+		Container: container,
+		TailLines: &tailLines,
+		Previous:  previous,
+	}).DoRaw(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get logs of container '%s' in pod '%s': %w", container, name, err)
+	}
+
+	return string(data), nil
+}
@@ -0,0 +1,238 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// EnvConfigPath is the environment variable used when --config is not set
+	EnvConfigPath = "OPENSTACK_K8S_MCP_CONFIG"
+
+	defaultNamespace             = "openstack"
+	defaultWaitTimeout           = 600
+	defaultWaitPollInterval      = 5
+	defaultDeploymentRequeueTime = 1
+	defaultOperationRetention    = 3600
+
+	// SingleClusterName names the single cluster used when no clusters are configured
+	SingleClusterName = "default"
+)
+
+// Config holds the server defaults and tool enablement loaded from the YAML config file
+type Config struct {
+	// DefaultNamespace is used by every tool when no namespace argument is given
+	DefaultNamespace string `json:"defaultNamespace,omitempty"`
+	// KubeContext selects a kubeconfig context instead of the current one.
+	// Only used when Clusters is empty.
+	KubeContext string `json:"kubeContext,omitempty"`
+	// Clusters lists the named clusters a tool call can select with its cluster argument
+	Clusters []ClusterConfig `json:"clusters,omitempty"`
+	// DefaultCluster is used when a tool call has no cluster argument (default: the first cluster)
+	DefaultCluster string `json:"defaultCluster,omitempty"`
+	// Wait holds the defaults for condition waits
+	Wait WaitConfig `json:"wait,omitempty"`
+	// DeploymentRequeueTime is set on OpenStackDataplaneDeployments created by the server
+	DeploymentRequeueTime int `json:"deploymentRequeueTime,omitempty"`
+	// Tools controls which tools are registered
+	Tools ToolsConfig `json:"tools,omitempty"`
+	// Operations holds the settings for operations started with async
+	Operations OperationsConfig `json:"operations,omitempty"`
+}
+
+// ClusterConfig names a kubeconfig context
+type ClusterConfig struct {
+	Name        string `json:"name"`
+	KubeContext string `json:"kubeContext,omitempty"`
+	// Kubeconfig is an optional kubeconfig file path (default: the standard loading rules)
+	Kubeconfig string `json:"kubeconfig,omitempty"`
+}
+
+// WaitConfig holds the default timeout and poll interval for condition waits, in seconds
+type WaitConfig struct {
+	Timeout      int `json:"timeout,omitempty"`
+	PollInterval int `json:"pollInterval,omitempty"`
+}
+
+// OperationsConfig holds the settings for background operations
+type OperationsConfig struct {
+	// Retention is how long completed operations are kept for get_operation, in seconds
+	Retention int `json:"retention,omitempty"`
+}
+
+// ToolsConfig holds the allow and deny lists of tool names.
+// An empty allow list enables every tool; the deny list is applied afterwards.
+type ToolsConfig struct {
+	Allow []string `json:"allow,omitempty"`
+	Deny  []string `json:"deny,omitempty"`
+}
+
+// Default returns the configuration used when no config file is given
+func Default() *Config {
+	return &Config{
+		DefaultNamespace: defaultNamespace,
+		Wait: WaitConfig{
+			Timeout:      defaultWaitTimeout,
+			PollInterval: defaultWaitPollInterval,
+		},
+		DeploymentRequeueTime: defaultDeploymentRequeueTime,
+		Operations: OperationsConfig{
+			Retention: defaultOperationRetention,
+		},
+	}
+}
+
+// Load reads and validates the config file at path. An empty path falls back to
+// the OPENSTACK_K8S_MCP_CONFIG environment variable, and to the defaults if neither is set.
+func Load(path string) (*Config, error) {
+	if path == "" {
+		path = os.Getenv(EnvConfigPath)
+	}
+
+	cfg := Default()
+	if path == "" {
+		return cfg, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file '%s': %w", path, err)
+	}
+
+	if err := yaml.UnmarshalStrict(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config file '%s': %w", path, err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config file '%s': %w", path, err)
+	}
+
+	return cfg, nil
+}
+
+// ClusterList returns the configured clusters, or a single cluster named
+// SingleClusterName using KubeContext when none are configured
+func (c *Config) ClusterList() []ClusterConfig {
+	if len(c.Clusters) == 0 {
+		return []ClusterConfig{{Name: SingleClusterName, KubeContext: c.KubeContext}}
+	}
+	return c.Clusters
+}
+
+// DefaultClusterName returns the cluster used when a tool call has no cluster argument
+func (c *Config) DefaultClusterName() string {
+	if c.DefaultCluster != "" {
+		return c.DefaultCluster
+	}
+	return c.ClusterList()[0].Name
+}
+
+// Validate checks the configuration for invalid values
+func (c *Config) Validate() error {
+	var errs []string
+
+	if msgs := validation.IsDNS1123Label(c.DefaultNamespace); len(msgs) > 0 {
+		errs = append(errs, fmt.Sprintf("defaultNamespace '%s' is not a valid namespace: %s", c.DefaultNamespace, strings.Join(msgs, ", ")))
+	}
+
+	if len(c.Clusters) > 0 && c.KubeContext != "" {
+		errs = append(errs, "kubeContext cannot be combined with clusters; set kubeContext on each cluster instead")
+	}
+
+	seen := make(map[string]bool, len(c.Clusters))
+	for i, cluster := range c.Clusters {
+		if msgs := validation.IsDNS1123Label(cluster.Name); len(msgs) > 0 {
+			errs = append(errs, fmt.Sprintf("clusters[%d].name '%s' is not a valid name: %s", i, cluster.Name, strings.Join(msgs, ", ")))
+		}
+		if seen[cluster.Name] {
+			errs = append(errs, fmt.Sprintf("clusters[%d].name '%s' is duplicated", i, cluster.Name))
+		}
+		seen[cluster.Name] = true
+	}
+
+	if c.DefaultCluster != "" && !contains(c.clusterNames(), c.DefaultCluster) {
+		errs = append(errs, fmt.Sprintf("defaultCluster '%s' is not one of the configured clusters", c.DefaultCluster))
+	}
+
+	if c.Wait.Timeout <= 0 {
+		errs = append(errs, fmt.Sprintf("wait.timeout must be greater than 0, got %d", c.Wait.Timeout))
+	}
+
+	if c.Wait.PollInterval <= 0 {
+		errs = append(errs, fmt.Sprintf("wait.pollInterval must be greater than 0, got %d", c.Wait.PollInterval))
+	} else if c.Wait.Timeout > 0 && c.Wait.PollInterval > c.Wait.Timeout {
+		errs = append(errs, fmt.Sprintf("wait.pollInterval (%d) must not be greater than wait.timeout (%d)", c.Wait.PollInterval, c.Wait.Timeout))
+	}
+
+	if c.DeploymentRequeueTime <= 0 {
+		errs = append(errs, fmt.Sprintf("deploymentRequeueTime must be greater than 0, got %d", c.DeploymentRequeueTime))
+	}
+
+	if c.Operations.Retention <= 0 {
+		errs = append(errs, fmt.Sprintf("operations.retention must be greater than 0, got %d", c.Operations.Retention))
+	}
+
+	allowed := make(map[string]bool, len(c.Tools.Allow))
+	for _, name := range c.Tools.Allow {
+		allowed[name] = true
+	}
+	for _, name := range c.Tools.Deny {
+		if allowed[name] {
+			errs = append(errs, fmt.Sprintf("tool '%s' is in both tools.allow and tools.deny", name))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
+// clusterNames returns the names of the clusters returned by ClusterList
+func (c *Config) clusterNames() []string {
+	clusters := c.ClusterList()
+	names := make([]string, len(clusters))
+	for i, cluster := range clusters {
+		names[i] = cluster.Name
+	}
+	return names
+}
+
+// ValidateToolNames checks that every tool named in the allow and deny lists is known
+func (c *Config) ValidateToolNames(known map[string]bool) error {
+	var unknown []string
+	for _, name := range append(append([]string{}, c.Tools.Allow...), c.Tools.Deny...) {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+
+	if len(unknown) > 0 {
+		return fmt.Errorf("unknown tool names in config: %s", strings.Join(unknown, ", "))
+	}
+
+	return nil
+}
+
+// ToolEnabled reports whether the tool with the given name should be registered
+func (c *Config) ToolEnabled(name string) bool {
+	if len(c.Tools.Allow) > 0 && !contains(c.Tools.Allow, name) {
+		return false
+	}
+	return !contains(c.Tools.Deny, name)
+}
+
+// contains checks if a string slice contains a specific string
+func contains(slice []string, item string) bool {
+	for _, s := range slice {
+		if s == item {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/dprince/openstack-k8s-mcp/internal/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	openstackv1beta1 "github.com/openstack-k8s-operators/openstack-operator/apis/core/v1beta1"
+)
+
+// Target version validation failures, returned as the structured error type
+const (
+	VersionErrorUnknown    = "UnknownVersion"
+	VersionErrorDowngrade  = "DowngradeRefused"
+	VersionErrorInProgress = "UpdateInProgress"
+)
+
+// AvailableVersion is a version known to the OpenStackVersion CR
+type AvailableVersion struct {
+	Version string `json:"version"`
+	// Available is set for status.availableVersion, the version the operator offers
+	Available bool `json:"available"`
+	Deployed  bool `json:"deployed"`
+	Targeted  bool `json:"targeted"`
+	// UpgradeTarget is set for versions newer than deployedVersion
+	UpgradeTarget bool `json:"upgradeTarget"`
+	// HasImageDefaults is set when status.containerImageVersionDefaults holds the images of the version
+	HasImageDefaults bool `json:"hasImageDefaults"`
+}
+
+// versionParts splits a version into its numeric and non-numeric parts,
+// e.g. "18.0.3-1" into ["18", "0", "3", "1"]
+func versionParts(version string) []string {
+	return strings.FieldsFunc(strings.TrimPrefix(version, "v"), func(r rune) bool {
+		return r == '.' || r == '-' || r == '+' || r == '_'
+	})
+}
+
+// compareVersions compares two versions part by part, numerically where both
+// parts are numbers. It returns -1, 0 or 1.
+func compareVersions(a, b string) int {
+	partsA, partsB := versionParts(a), versionParts(b)
+	for i := 0; i < len(partsA) && i < len(partsB); i++ {
+		numA, errA := strconv.Atoi(partsA[i])
+		numB, errB := strconv.Atoi(partsB[i])
+		switch {
+		case errA == nil && errB == nil:
+			if numA != numB {
+				if numA < numB {
+					return -1
+				}
+				return 1
+			}
+		case partsA[i] != partsB[i]:
+			if partsA[i] < partsB[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case len(partsA) < len(partsB):
+		return -1
+	case len(partsA) > len(partsB):
+		return 1
+	}
+	return 0
+}
+
+// isVersionLike reports whether a version starts with a digit, optionally after a "v"
+func isVersionLike(version string) bool {
+	version = strings.TrimPrefix(version, "v")
+	return version != "" && unicode.IsDigit(rune(version[0]))
+}
+
+// availableVersions returns the versions the OpenStackVersion CR knows about:
+// availableVersion, deployedVersion, targetVersion and the versions with
+// container image defaults, newest first
+func availableVersions(osVersion *openstackv1beta1.OpenStackVersion) []AvailableVersion {
+	known := map[string]bool{}
+	if osVersion.Status.AvailableVersion != nil {
+		known[*osVersion.Status.AvailableVersion] = true
+	}
+	if osVersion.Status.DeployedVersion != nil {
+		known[*osVersion.Status.DeployedVersion] = true
+	}
+	if osVersion.Spec.TargetVersion != "" {
+		known[osVersion.Spec.TargetVersion] = true
+	}
+	for version := range osVersion.Status.ContainerImageVersionDefaults {
+		known[version] = true
+	}
+
+	versions := []AvailableVersion{}
+	for version := range known {
+		_, hasDefaults := osVersion.Status.ContainerImageVersionDefaults[version]
+		deployed := osVersion.Status.DeployedVersion != nil && *osVersion.Status.DeployedVersion == version
+		versions = append(versions, AvailableVersion{
+			Version:          version,
+			Available:        osVersion.Status.AvailableVersion != nil && *osVersion.Status.AvailableVersion == version,
+			Deployed:         deployed,
+			Targeted:         osVersion.Spec.TargetVersion == version,
+			UpgradeTarget:    osVersion.Status.DeployedVersion == nil || compareVersions(version, *osVersion.Status.DeployedVersion) > 0,
+			HasImageDefaults: hasDefaults,
+		})
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return compareVersions(versions[i].Version, versions[j].Version) > 0
+	})
+	return versions
+}
+
+// VersionCheckFailure is a reason to refuse a target version
+type VersionCheckFailure struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// checkTargetVersion returns the reasons to refuse setting targetVersion on
+// the OpenStackVersion CR: the operator does not know the version, it is
+// older than deployedVersion, or another update is still in progress
+func checkTargetVersion(osVersion *openstackv1beta1.OpenStackVersion, targetVersion string) []VersionCheckFailure {
+	failures := []VersionCheckFailure{}
+	deployedVersion := osVersion.Status.DeployedVersion
+
+	isAvailable := osVersion.Status.AvailableVersion != nil && *osVersion.Status.AvailableVersion == targetVersion
+	_, hasDefaults := osVersion.Status.ContainerImageVersionDefaults[targetVersion]
+	isDeployed := deployedVersion != nil && *deployedVersion == targetVersion
+	if !isAvailable && !hasDefaults && !isDeployed {
+		known := []string{}
+		for _, version := range availableVersions(osVersion) {
+			known = append(known, version.Version)
+		}
+		failures = append(failures, VersionCheckFailure{
+			Type:    VersionErrorUnknown,
+			Message: fmt.Sprintf("Version '%s' is not known to OpenStackVersion '%s'; known versions: %v", targetVersion, osVersion.Name, known),
+		})
+	}
+
+	if deployedVersion != nil && isVersionLike(targetVersion) && compareVersions(targetVersion, *deployedVersion) < 0 {
+		failures = append(failures, VersionCheckFailure{
+			Type:    VersionErrorDowngrade,
+			Message: fmt.Sprintf("Version '%s' is older than deployedVersion '%s'; downgrades are not supported", targetVersion, *deployedVersion),
+		})
+	}
+
+	currentTarget := osVersion.Spec.TargetVersion
+	if deployedVersion != nil && currentTarget != *deployedVersion && targetVersion != currentTarget {
+		failures = append(failures, VersionCheckFailure{
+			Type:    VersionErrorInProgress,
+			Message: fmt.Sprintf("An update from '%s' to '%s' is still in progress; let it finish before targeting '%s'", *deployedVersion, currentTarget, targetVersion),
+		})
+	}
+
+	return failures
+}
+
+// ListAvailableOpenStackVersionsHandler handles the list_available_openstack_versions tool call
+func ListAvailableOpenStackVersionsHandler(k8sClient *client.K8sClient, settings Settings) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract parameters
+		namespace, ok := request.GetArguments()["namespace"].(string)
+		if !ok || namespace == "" {
+			namespace = settings.DefaultNamespace
+		}
+
+		name, ok := request.GetArguments()["name"].(string)
+
+		var osVersion *openstackv1beta1.OpenStackVersion
+		var err error
+
+		if ok && name != "" {
+			// Query the specific OpenStackVersion CR by name
+			osVersion, err = k8sClient.GetOpenStackVersion(ctx, namespace, name)
+			if err != nil {
+				return newStructuredError(
+					ErrorCodeK8sAPIError,
+					fmt.Sprintf("Failed to get OpenStackVersion '%s' in namespace '%s': %v", name, namespace, err),
+					"KubernetesAPIError",
+				), nil
+			}
+		} else {
+			// Auto-discover: List all OpenStackVersion CRs and use the first one
+			versions, err := k8sClient.ListOpenStackVersions(ctx, namespace)
+			if err != nil {
+				return newStructuredError(
+					ErrorCodeK8sAPIError,
+					fmt.Sprintf("Failed to list OpenStackVersions in namespace '%s': %v", namespace, err),
+					"KubernetesAPIError",
+				), nil
+			}
+
+			if len(versions) == 0 {
+				return newStructuredError(
+					ErrorCodeNotFound,
+					fmt.Sprintf("No OpenStackVersion CR found in namespace '%s'", namespace),
+					"ResourceNotFound",
+				), nil
+			}
+
+			osVersion = &versions[0]
+		}
+
+		versions := availableVersions(osVersion)
+		upgradeTargets := []string{}
+		for _, version := range versions {
+			if version.UpgradeTarget && version.Available {
+				upgradeTargets = append(upgradeTargets, version.Version)
+			}
+		}
+
+		// Build response
+		response := map[string]interface{}{
+			"name":             osVersion.Name,
+			"namespace":        osVersion.Namespace,
+			"targetVersion":    osVersion.Spec.TargetVersion,
+			"availableVersion": osVersion.Status.AvailableVersion,
+			"deployedVersion":  osVersion.Status.DeployedVersion,
+			"versions":         versions,
+			"upgradeTargets":   upgradeTargets,
+		}
+
+		switch {
+		case osVersion.Status.DeployedVersion != nil && osVersion.Spec.TargetVersion != *osVersion.Status.DeployedVersion:
+			response["message"] = fmt.Sprintf("An update to '%s' is in progress.", osVersion.Spec.TargetVersion)
+		case len(upgradeTargets) == 0:
+			response["message"] = "No newer version is available."
+		default:
+			response["message"] = fmt.Sprintf("Version '%s' is available to update to.", upgradeTargets[0])
+		}
+
+		// Convert response to JSON
+		jsonData, err := json.MarshalIndent(response, "", "  ")
+		if err != nil {
+			return newStructuredError(
+				ErrorCodeMarshalError,
+				fmt.Sprintf("Failed to marshal response: %v", err),
+				"MarshalError",
+			), nil
+		}
+
+		return mcp.NewToolResultText(string(jsonData)), nil
+	}
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/dprince/openstack-k8s-mcp/internal/client"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// HandlerFunc is the signature shared by every tool handler
+type HandlerFunc = func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error)
+
+// ClusterHandler builds newHandler once per registered cluster and dispatches
+// each call to the cluster named by its optional cluster argument
+func ClusterHandler(registry *client.Registry, settings Settings, newHandler func(k8sClient *client.K8sClient, settings Settings) HandlerFunc) HandlerFunc {
+	clusterHandlers := make(map[string]HandlerFunc, len(registry.Names()))
+	for _, name := range registry.Names() {
+		k8sClient, _ := registry.Get(name)
+		clusterHandlers[name] = newHandler(k8sClient, settings)
+	}
+
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		cluster, _ := request.GetArguments()["cluster"].(string)
+		if cluster == "" {
+			cluster = registry.Default()
+		}
+
+		handler, ok := clusterHandlers[cluster]
+		if !ok {
+			return newStructuredError(
+				ErrorCodeInvalidParameter,
+				fmt.Sprintf("Unknown cluster '%s' (known clusters: %s)", cluster, strings.Join(registry.Names(), ", ")),
+				"ParameterValidationError",
+			), nil
+		}
+
+		return handler(ctx, request)
+	}
+}
+
+// ListClustersHandler handles the list_clusters tool call
+func ListClustersHandler(registry *client.Registry) HandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		clusters := make([]map[string]interface{}, 0, len(registry.Names()))
+		for _, name := range registry.Names() {
+			k8sClient, _ := registry.Get(name)
+			clusters = append(clusters, map[string]interface{}{
+				"name":        name,
+				"kubeContext": k8sClient.KubeContext(),
+				"server":      k8sClient.Host(),
+				"default":     name == registry.Default(),
+				"readOnly":    k8sClient.ReadOnly(),
+			})
+		}
+
+		// Convert response to JSON
+		jsonData, err := json.MarshalIndent(map[string]interface{}{
+			"defaultCluster": registry.Default(),
+			"clusters":       clusters,
+		}, "", "  ")
+		if err != nil {
+			return newStructuredError(
+				ErrorCodeMarshalError,
+				fmt.Sprintf("Failed to marshal response: %v", err),
+				"MarshalError",
+			), nil
+		}
+
+		return mcp.NewToolResultText(string(jsonData)), nil
+	}
+}
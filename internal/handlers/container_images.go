@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	openstackv1beta1 "github.com/openstack-k8s-operators/openstack-operator/apis/core/v1beta1"
+)
+
+// imageSet maps image keys to image references. Keys are the JSON field names
+// of the OpenStackVersion container images, e.g. "novaAPIImage"; per-backend
+// images are keyed "cinderVolumeImages/<backend>" and "manilaShareImages/<backend>".
+type imageSet map[string]string
+
+// defaultBackendKeys maps the single backend images of ContainerDefaults to
+// the key of the default backend in ContainerImages
+var defaultBackendKeys = map[string]string{
+	"cinderVolumeImage": "cinderVolumeImages/default",
+	"manilaShareImage":  "manilaShareImages/default",
+}
+
+// apacheDerivedImages are set by the operator to apacheImage once the
+// service using them is applied
+var apacheDerivedImages = []string{"octaviaApacheImage", "ceilometerProxyImage"}
+
+// imageServiceOverrides maps image keys whose prefix is not the service name
+var imageServiceOverrides = map[string]string{
+	"infraDnsmasqImage":             "dnsmasq",
+	"infraMemcachedImage":           "memcached",
+	"infraRedisImage":               "redis",
+	"openstackClientImage":          "openstackclient",
+	"openstackNetworkExporterImage": "telemetry",
+	"osContainerImage":              "os",
+}
+
+// dataplaneImageKeys are the images run on the dataplane nodes, besides the
+// edpm* images
+var dataplaneImageKeys = map[string]bool{
+	"ovnControllerImage":            true,
+	"novaComputeImage":              true,
+	"ceilometerComputeImage":        true,
+	"ceilometerIpmiImage":           true,
+	"openstackNetworkExporterImage": true,
+}
+
+// flattenImages converts a container images struct of the OpenStackVersion
+// API into an imageSet
+func flattenImages(images interface{}) (imageSet, error) {
+	data, err := json.Marshal(images)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal container images: %w", err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal container images: %w", err)
+	}
+
+	set := imageSet{}
+	for key, value := range fields {
+		switch v := value.(type) {
+		case string:
+			if backendKey, ok := defaultBackendKeys[key]; ok {
+				key = backendKey
+			}
+			set[key] = v
+		case map[string]interface{}:
+			for backend, image := range v {
+				if image, ok := image.(string); ok {
+					set[key+"/"+backend] = image
+				}
+			}
+		}
+	}
+	return set, nil
+}
+
+// statusImages returns the images of status.containerImages, the images
+// the operator rolls out for spec.targetVersion
+func statusImages(osVersion *openstackv1beta1.OpenStackVersion) (imageSet, error) {
+	return flattenImages(osVersion.Status.ContainerImages)
+}
+
+// customImages returns the images overridden in spec.customContainerImages
+func customImages(osVersion *openstackv1beta1.OpenStackVersion) (imageSet, error) {
+	return flattenImages(osVersion.Spec.CustomContainerImages)
+}
+
+// trackedCustomImages returns the custom images the operator recorded for
+// version, falling back to spec.customContainerImages
+func trackedCustomImages(osVersion *openstackv1beta1.OpenStackVersion, version string) (imageSet, error) {
+	if tracked, ok := osVersion.Status.TrackedCustomImages[version]; ok {
+		return flattenImages(tracked)
+	}
+	return customImages(osVersion)
+}
+
+// versionImages returns the images the operator rolls out for version: the
+// defaults of that version with the custom images applied. It returns false
+// when the OpenStackVersion has no defaults for version.
+func versionImages(osVersion *openstackv1beta1.OpenStackVersion, version string, custom imageSet) (imageSet, bool, error) {
+	defaults, ok := osVersion.Status.ContainerImageVersionDefaults[version]
+	if !ok || defaults == nil {
+		return nil, false, nil
+	}
+
+	set, err := flattenImages(defaults)
+	if err != nil {
+		return nil, true, err
+	}
+	rolledOut, err := statusImages(osVersion)
+	if err != nil {
+		return nil, true, err
+	}
+	if apache, ok := set["apacheImage"]; ok {
+		for _, key := range apacheDerivedImages {
+			if _, applied := rolledOut[key]; applied {
+				set[key] = apache
+			}
+		}
+	}
+
+	// Backends without a custom image run the default backend image
+	backends := map[string]map[string]*string{
+		"cinderVolumeImages": osVersion.Status.ContainerImages.CinderVolumeImages,
+		"manilaShareImages":  osVersion.Status.ContainerImages.ManilaShareImages,
+	}
+	for field, images := range backends {
+		defaultImage, ok := set[field+"/default"]
+		if !ok {
+			continue
+		}
+		for backend := range images {
+			set[field+"/"+backend] = defaultImage
+		}
+	}
+
+	for key, image := range custom {
+		set[key] = image
+	}
+	return set, true, nil
+}
+
+// imageService returns the service an image key belongs to, e.g. "nova" for
+// "novaAPIImage" and "cinder" for "cinderVolumeImages/ceph"
+func imageService(key string) string {
+	key, _, _ = strings.Cut(key, "/")
+	if service, ok := imageServiceOverrides[key]; ok {
+		return service
+	}
+	for i, r := range key {
+		if unicode.IsUpper(r) {
+			return key[:i]
+		}
+	}
+	return strings.TrimSuffix(key, "Images")
+}
+
+// isDataplaneImage reports whether an image key is run on the dataplane nodes
+func isDataplaneImage(key string) bool {
+	return strings.HasPrefix(key, "edpm") || dataplaneImageKeys[key]
+}
+
+// ServiceImage is a container image of a service, as deployed and as rolled
+// out for the target version
+type ServiceImage struct {
+	Image    string `json:"image"`
+	Deployed string `json:"deployed,omitempty"`
+	Target   string `json:"target,omitempty"`
+	// Custom is set when the image is overridden in spec.customContainerImages
+	Custom bool `json:"custom,omitempty"`
+}
+
+// serviceImages groups the deployed and target images by service. When
+// services is not empty, only those services (case-insensitive) are returned.
+func serviceImages(deployed, target, custom imageSet, services []string) map[string][]ServiceImage {
+	wanted := map[string]bool{}
+	for _, service := range services {
+		wanted[strings.ToLower(service)] = true
+	}
+
+	grouped := map[string][]ServiceImage{}
+	for _, key := range imageKeys(deployed, target) {
+		service := imageService(key)
+		if len(wanted) > 0 && !wanted[strings.ToLower(service)] {
+			continue
+		}
+		_, isCustom := custom[key]
+		grouped[service] = append(grouped[service], ServiceImage{
+			Image:    key,
+			Deployed: deployed[key],
+			Target:   target[key],
+			Custom:   isCustom,
+		})
+	}
+	return grouped
+}
+
+// imageKeys returns the keys of all image sets, sorted
+func imageKeys(sets ...imageSet) []string {
+	seen := map[string]bool{}
+	keys := []string{}
+	for _, set := range sets {
+		for key := range set {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
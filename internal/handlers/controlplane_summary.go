@@ -0,0 +1,276 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/dprince/openstack-k8s-mcp/internal/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	openstackv1beta1 "github.com/openstack-k8s-operators/openstack-operator/apis/core/v1beta1"
+)
+
+// controlPlaneService is a service section of the OpenStackControlPlane spec
+type controlPlaneService struct {
+	// Section is the JSON field of the section in the spec
+	Section string
+	// ReadyCondition is the condition reporting the service on the OpenStackControlPlane
+	ReadyCondition string
+	// ImageServices are the services of the OpenStackVersion images the section runs, see imageService
+	ImageServices []string
+	// AlwaysEnabled is set for sections without an enabled field
+	AlwaysEnabled bool
+}
+
+// controlPlaneServices are the service sections of the OpenStackControlPlane
+// spec, in the order of the spec
+var controlPlaneServices = []controlPlaneService{
+	{Section: "dns", ReadyCondition: string(openstackv1beta1.OpenStackControlPlaneDNSReadyCondition), ImageServices: []string{"dnsmasq"}},
+	{Section: "keystone", ReadyCondition: string(openstackv1beta1.OpenStackControlPlaneKeystoneAPIReadyCondition), ImageServices: []string{"keystone"}},
+	{Section: "placement", ReadyCondition: string(openstackv1beta1.OpenStackControlPlanePlacementAPIReadyCondition), ImageServices: []string{"placement"}},
+	{Section: "glance", ReadyCondition: string(openstackv1beta1.OpenStackControlPlaneGlanceReadyCondition), ImageServices: []string{"glance"}},
+	{Section: "cinder", ReadyCondition: string(openstackv1beta1.OpenStackControlPlaneCinderReadyCondition), ImageServices: []string{"cinder"}},
+	{Section: "galera", ReadyCondition: string(openstackv1beta1.OpenStackControlPlaneMariaDBReadyCondition), ImageServices: []string{"mariadb"}},
+	{Section: "rabbitmq", ReadyCondition: string(openstackv1beta1.OpenStackControlPlaneRabbitMQReadyCondition), ImageServices: []string{"rabbitmq"}},
+	{Section: "memcached", ReadyCondition: string(openstackv1beta1.OpenStackControlPlaneMemcachedReadyCondition), ImageServices: []string{"memcached"}},
+	{Section: "ovn", ReadyCondition: string(openstackv1beta1.OpenStackControlPlaneOVNReadyCondition), ImageServices: []string{"ovn"}},
+	{Section: "neutron", ReadyCondition: string(openstackv1beta1.OpenStackControlPlaneNeutronReadyCondition), ImageServices: []string{"neutron"}},
+	{Section: "nova", ReadyCondition: string(openstackv1beta1.OpenStackControlPlaneNovaReadyCondition), ImageServices: []string{"nova"}},
+	{Section: "heat", ReadyCondition: string(openstackv1beta1.OpenStackControlPlaneHeatReadyCondition), ImageServices: []string{"heat"}},
+	{Section: "ironic", ReadyCondition: string(openstackv1beta1.OpenStackControlPlaneIronicReadyCondition), ImageServices: []string{"ironic"}},
+	{Section: "manila", ReadyCondition: string(openstackv1beta1.OpenStackControlPlaneManilaReadyCondition), ImageServices: []string{"manila"}},
+	{Section: "horizon", ReadyCondition: string(openstackv1beta1.OpenStackControlPlaneHorizonReadyCondition), ImageServices: []string{"horizon"}},
+	{Section: "telemetry", ReadyCondition: string(openstackv1beta1.OpenStackControlPlaneTelemetryReadyCondition), ImageServices: []string{"telemetry", "ceilometer", "aodh", "cloudkitty", "ksm"}},
+	{Section: "swift", ReadyCondition: string(openstackv1beta1.OpenStackControlPlaneSwiftReadyCondition), ImageServices: []string{"swift"}},
+	{Section: "octavia", ReadyCondition: string(openstackv1beta1.OpenStackControlPlaneOctaviaReadyCondition), ImageServices: []string{"octavia"}},
+	{Section: "designate", ReadyCondition: string(openstackv1beta1.OpenStackControlPlaneDesignateReadyCondition), ImageServices: []string{"designate"}},
+	{Section: "barbican", ReadyCondition: string(openstackv1beta1.OpenStackControlPlaneBarbicanReadyCondition), ImageServices: []string{"barbican"}},
+	{Section: "redis", ReadyCondition: string(openstackv1beta1.OpenStackControlPlaneRedisReadyCondition), ImageServices: []string{"redis"}},
+	{Section: "openstackclient", ReadyCondition: string(openstackv1beta1.OpenStackControlPlaneClientReadyCondition), ImageServices: []string{"openstackclient"}, AlwaysEnabled: true},
+	{Section: "watcher", ReadyCondition: string(openstackv1beta1.OpenStackControlPlaneWatcherReadyCondition), ImageServices: []string{"watcher"}},
+}
+
+// controlPlaneSummaryColumns are the columns of the summarize_openstack_controlplane rows
+var controlPlaneSummaryColumns = []string{"service", "enabled", "replicas", "customImages", "ready", "message"}
+
+// replicaComponent names the component of a template path, e.g.
+// "cell1/conductor" for cellTemplates.cell1.conductorServiceTemplate
+func replicaComponent(path []string) string {
+	parts := []string{}
+	for _, segment := range path {
+		if segment == "template" || segment == "templates" || strings.HasSuffix(segment, "Templates") {
+			continue
+		}
+		segment = strings.TrimSuffix(segment, "ServiceTemplate")
+		segment = strings.TrimSuffix(segment, "Template")
+		parts = append(parts, segment)
+	}
+	return strings.Join(parts, "/")
+}
+
+// collectReplicas finds every replicas field below fields, keyed by component
+func collectReplicas(fields map[string]interface{}, path []string, replicas map[string]int64) {
+	for key, value := range fields {
+		if key == "replicas" {
+			if count, ok := int64Field(fields, key); ok {
+				replicas[replicaComponent(path)] = count
+			}
+			continue
+		}
+		if nested, ok := value.(map[string]interface{}); ok {
+			collectReplicas(nested, append(append([]string{}, path...), key), replicas)
+		}
+	}
+}
+
+// formatReplicas formats replicas by component, e.g. "3" for a single
+// component or "api=3, scheduler=1" for several
+func formatReplicas(replicas map[string]int64) string {
+	if count, ok := replicas[""]; ok && len(replicas) == 1 {
+		return fmt.Sprintf("%d", count)
+	}
+	components := make([]string, 0, len(replicas))
+	for component := range replicas {
+		components = append(components, component)
+	}
+	sort.Strings(components)
+
+	parts := make([]string, len(components))
+	for i, component := range components {
+		name := component
+		if name == "" {
+			name = "default"
+		}
+		parts[i] = fmt.Sprintf("%s=%d", name, replicas[component])
+	}
+	return strings.Join(parts, ", ")
+}
+
+// controlPlaneConditions returns the conditions of an OpenStackControlPlane by type
+func controlPlaneConditions(controlPlane map[string]interface{}) map[string]map[string]interface{} {
+	conditions := map[string]map[string]interface{}{}
+	status, _ := controlPlane["status"].(map[string]interface{})
+	list, _ := status["conditions"].([]interface{})
+	for _, condInterface := range list {
+		if cond, ok := condInterface.(map[string]interface{}); ok {
+			condType, _ := cond["type"].(string)
+			conditions[condType] = cond
+		}
+	}
+	return conditions
+}
+
+// summarizeControlPlane builds one row per service section of the
+// OpenStackControlPlane, see controlPlaneSummaryColumns. custom holds the
+// custom images of the OpenStackVersion.
+func summarizeControlPlane(controlPlane map[string]interface{}, custom imageSet) [][]interface{} {
+	spec, _ := controlPlane["spec"].(map[string]interface{})
+	conditions := controlPlaneConditions(controlPlane)
+
+	rows := [][]interface{}{}
+	for _, service := range controlPlaneServices {
+		section, _ := spec[service.Section].(map[string]interface{})
+		enabled, _ := section["enabled"].(bool)
+		if service.AlwaysEnabled {
+			enabled = true
+		}
+
+		replicas := ""
+		if enabled {
+			counts := map[string]int64{}
+			collectReplicas(section, nil, counts)
+			replicas = formatReplicas(counts)
+		}
+
+		customKeys := []string{}
+		for _, key := range imageKeys(custom) {
+			if contains(service.ImageServices, imageService(key)) {
+				customKeys = append(customKeys, key)
+			}
+		}
+
+		ready, message := "", ""
+		if cond, ok := conditions[service.ReadyCondition]; ok {
+			ready, _ = cond["status"].(string)
+			if ready != client.ConditionTrue {
+				message, _ = cond["message"].(string)
+			}
+		}
+
+		rows = append(rows, []interface{}{service.Section, enabled, replicas, customKeys, ready, message})
+	}
+	return rows
+}
+
+// SummarizeOpenStackControlPlaneHandler handles the summarize_openstack_controlplane tool call
+func SummarizeOpenStackControlPlaneHandler(k8sClient *client.K8sClient, settings Settings) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract parameters
+		namespace, ok := request.GetArguments()["namespace"].(string)
+		if !ok || namespace == "" {
+			namespace = settings.DefaultNamespace
+		}
+
+		name, ok := request.GetArguments()["name"].(string)
+
+		var controlPlane map[string]interface{}
+		var err error
+
+		if ok && name != "" {
+			// Query the specific OpenStackControlPlane CR by name
+			controlPlane, err = k8sClient.GetOpenStackControlPlane(ctx, namespace, name)
+			if err != nil {
+				return newStructuredError(
+					ErrorCodeK8sAPIError,
+					fmt.Sprintf("Failed to get OpenStackControlPlane '%s' in namespace '%s': %v", name, namespace, err),
+					"KubernetesAPIError",
+				), nil
+			}
+		} else {
+			// Auto-discover: List all OpenStackControlPlane CRs and use the first one
+			controlPlanes, err := k8sClient.ListOpenStackControlPlanes(ctx, namespace)
+			if err != nil {
+				return newStructuredError(
+					ErrorCodeK8sAPIError,
+					fmt.Sprintf("Failed to list OpenStackControlPlanes in namespace '%s': %v", namespace, err),
+					"KubernetesAPIError",
+				), nil
+			}
+
+			if len(controlPlanes) == 0 {
+				return newStructuredError(
+					ErrorCodeNotFound,
+					fmt.Sprintf("No OpenStackControlPlane CR found in namespace '%s'", namespace),
+					"ResourceNotFound",
+				), nil
+			}
+
+			controlPlane = controlPlanes[0]
+		}
+
+		metadata, _ := controlPlane["metadata"].(map[string]interface{})
+		name, _ = metadata["name"].(string)
+
+		// Custom images live on the OpenStackVersion, named after the control plane
+		warnings := []string{}
+		custom := imageSet{}
+		osVersion, err := k8sClient.GetOpenStackVersion(ctx, namespace, name)
+		if err != nil {
+			versions, listErr := k8sClient.ListOpenStackVersions(ctx, namespace)
+			if listErr == nil && len(versions) > 0 {
+				osVersion, err = &versions[0], nil
+			}
+		}
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("Custom images are not shown: no OpenStackVersion found: %v", err))
+		} else if custom, err = customImages(osVersion); err != nil {
+			return newStructuredError(ErrorCodeMarshalError, err.Error(), "MarshalError"), nil
+		}
+
+		rows := summarizeControlPlane(controlPlane, custom)
+
+		enabled, notReady := 0, []string{}
+		for _, row := range rows {
+			if row[1].(bool) {
+				enabled++
+				if row[4] != client.ConditionTrue {
+					notReady = append(notReady, row[0].(string))
+				}
+			}
+		}
+
+		// Build response
+		response := map[string]interface{}{
+			"name":      name,
+			"namespace": metadata["namespace"],
+			"columns":   controlPlaneSummaryColumns,
+			"rows":      rows,
+		}
+		if len(custom) > 0 {
+			response["customImages"] = custom
+		}
+		if len(warnings) > 0 {
+			response["warnings"] = warnings
+		}
+
+		if len(notReady) == 0 {
+			response["message"] = fmt.Sprintf("%d of %d services are enabled and all are ready.", enabled, len(rows))
+		} else {
+			response["message"] = fmt.Sprintf("%d of %d services are enabled; not ready: %s.", enabled, len(rows), strings.Join(notReady, ", "))
+		}
+
+		// Convert response to compact JSON: keeping the result small is the point of the summary
+		jsonData, err := json.Marshal(response)
+		if err != nil {
+			return newStructuredError(
+				ErrorCodeMarshalError,
+				fmt.Sprintf("Failed to marshal response: %v", err),
+				"MarshalError",
+			), nil
+		}
+
+		return mcp.NewToolResultText(string(jsonData)), nil
+	}
+}
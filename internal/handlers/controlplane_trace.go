@@ -0,0 +1,401 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/dprince/openstack-k8s-mcp/internal/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// conditionKindOverrides maps the service named by a controlplane condition
+// to the kinds of its CRs where the kind does not start with the service name
+var conditionKindOverrides = map[string][]string{
+	"MariaDB": {"Galera"},
+	"Client":  {"OpenStackClient"},
+	"DNS":     {"DNSMasq"},
+}
+
+// conditionService returns the service named by an OpenStackControlPlane
+// condition, e.g. "Nova" for OpenStackControlPlaneNovaReady and
+// OpenStackControlPlaneExposeNovaReady
+func conditionService(condType string) string {
+	service := strings.TrimPrefix(condType, "OpenStackControlPlane")
+	service = strings.TrimPrefix(service, "Expose")
+	service = strings.TrimSuffix(service, "ReadyCondition")
+	return strings.TrimSuffix(service, "Ready")
+}
+
+// conditionMatchesKind reports whether a CR of kind reports on the service
+// named by a controlplane condition
+func conditionMatchesKind(condType, kind string) bool {
+	service := conditionService(condType)
+	if service == "" {
+		return false
+	}
+	prefixes, ok := conditionKindOverrides[service]
+	if !ok {
+		prefixes = []string{service}
+	}
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(strings.ToLower(kind), strings.ToLower(prefix)) {
+			return true
+		}
+	}
+	return false
+}
+
+// ConditionDetail is a condition of an OpenStack CR
+type ConditionDetail struct {
+	Type    string `json:"type"`
+	Status  string `json:"status"`
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// ResourceNode is an OpenStack CR in the condition tree, with the owned CRs
+// that are failing
+type ResourceNode struct {
+	Kind       string `json:"kind"`
+	APIVersion string `json:"apiVersion"`
+	Name       string `json:"name"`
+	Ready      string `json:"ready"`
+	// FailingConditions are the conditions that are not True
+	FailingConditions []ConditionDetail `json:"failingConditions,omitempty"`
+	Children          []*ResourceNode   `json:"children,omitempty"`
+	// HealthyChildren counts the owned CRs left out of Children because all their conditions are True
+	HealthyChildren int `json:"healthyChildren,omitempty"`
+	// RootCause is set on failing CRs that own no failing CR: the deepest failing conditions
+	RootCause bool `json:"rootCause,omitempty"`
+}
+
+// ControlPlaneConditionTree is a controlplane condition and the CRs of its service
+type ControlPlaneConditionTree struct {
+	ConditionDetail
+	Resources []*ResourceNode `json:"resources"`
+}
+
+// RootCause is a failing CR that owns no failing CR, with the path leading to it
+type RootCause struct {
+	Path              string            `json:"path"`
+	Kind              string            `json:"kind"`
+	Name              string            `json:"name"`
+	FailingConditions []ConditionDetail `json:"failingConditions"`
+	// UID identifies the CR to diagnose_not_ready
+	UID string `json:"-"`
+}
+
+// openStackObjects indexes the OpenStack CRs of a namespace by owner
+type openStackObjects struct {
+	// children maps an owner UID to the objects it owns
+	children map[string][]map[string]interface{}
+}
+
+// newOpenStackObjects indexes objects by the UIDs of their owners
+func newOpenStackObjects(objects []map[string]interface{}) *openStackObjects {
+	index := &openStackObjects{children: map[string][]map[string]interface{}{}}
+	for _, obj := range objects {
+		metadata, _ := obj["metadata"].(map[string]interface{})
+		owners, _ := metadata["ownerReferences"].([]interface{})
+		for _, ownerInterface := range owners {
+			owner, ok := ownerInterface.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if uid, _ := owner["uid"].(string); uid != "" {
+				index.children[uid] = append(index.children[uid], obj)
+			}
+		}
+	}
+	for _, children := range index.children {
+		sort.Slice(children, func(i, j int) bool {
+			return objectKey(children[i]) < objectKey(children[j])
+		})
+	}
+	return index
+}
+
+// objectKey returns "Kind/name" of an object
+func objectKey(obj map[string]interface{}) string {
+	kind, _ := obj["kind"].(string)
+	metadata, _ := obj["metadata"].(map[string]interface{})
+	name, _ := metadata["name"].(string)
+	return kind + "/" + name
+}
+
+// objectUID returns the UID of an object
+func objectUID(obj map[string]interface{}) string {
+	metadata, _ := obj["metadata"].(map[string]interface{})
+	uid, _ := metadata["uid"].(string)
+	return uid
+}
+
+// objectConditionDetails returns the Ready status of an object and its
+// conditions that are not True
+func objectConditionDetails(obj map[string]interface{}) (string, []ConditionDetail) {
+	status, _ := obj["status"].(map[string]interface{})
+	conditions, _ := status["conditions"].([]interface{})
+
+	ready := ""
+	failing := []ConditionDetail{}
+	for _, condInterface := range conditions {
+		cond, ok := condInterface.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		detail := ConditionDetail{}
+		detail.Type, _ = cond["type"].(string)
+		detail.Status, _ = cond["status"].(string)
+		detail.Reason, _ = cond["reason"].(string)
+		detail.Message, _ = cond["message"].(string)
+		if detail.Type == DefaultWaitCondition {
+			ready = detail.Status
+		}
+		if detail.Status != client.ConditionTrue {
+			failing = append(failing, detail)
+		}
+	}
+	return ready, failing
+}
+
+// resourceTree builds the node of obj and, below it, the owned CRs that are
+// failing, recording the failing CRs without failing children as root causes.
+// visited guards against ownership cycles.
+func (index *openStackObjects) resourceTree(obj map[string]interface{}, path string, visited map[string]bool, rootCauses *[]RootCause) *ResourceNode {
+	metadata, _ := obj["metadata"].(map[string]interface{})
+	node := &ResourceNode{}
+	node.Kind, _ = obj["kind"].(string)
+	node.APIVersion, _ = obj["apiVersion"].(string)
+	node.Name, _ = metadata["name"].(string)
+	node.Ready, node.FailingConditions = objectConditionDetails(obj)
+	path = fmt.Sprintf("%s > %s", path, objectKey(obj))
+
+	uid := objectUID(obj)
+	visited[uid] = true
+	for _, child := range index.children[uid] {
+		if visited[objectUID(child)] {
+			continue
+		}
+		childNode := index.resourceTree(child, path, visited, rootCauses)
+		if len(childNode.FailingConditions) == 0 && len(childNode.Children) == 0 {
+			node.HealthyChildren++
+			continue
+		}
+		node.Children = append(node.Children, childNode)
+	}
+
+	if len(node.FailingConditions) > 0 && len(node.Children) == 0 {
+		node.RootCause = true
+		*rootCauses = append(*rootCauses, RootCause{
+			Path:              path,
+			Kind:              node.Kind,
+			Name:              node.Name,
+			FailingConditions: node.FailingConditions,
+			UID:               uid,
+		})
+	}
+	return node
+}
+
+// controlPlaneTrace is the result of tracing the OpenStackControlPlane conditions
+type controlPlaneTrace struct {
+	name       string
+	traced     []ConditionDetail
+	trees      []ControlPlaneConditionTree
+	rootCauses []RootCause
+	warnings   []string
+}
+
+// traceControlPlane traces the conditions of the OpenStackControlPlane named
+// by the request, or of the first one in the namespace, into the service CRs
+// owned by it. It returns a structured error result on failure.
+func traceControlPlane(ctx context.Context, k8sClient *client.K8sClient, request mcp.CallToolRequest, namespace string) (*controlPlaneTrace, *mcp.CallToolResult) {
+	name, ok := request.GetArguments()["name"].(string)
+
+	var controlPlane map[string]interface{}
+	var err error
+
+	if ok && name != "" {
+		// Query the specific OpenStackControlPlane CR by name
+		controlPlane, err = k8sClient.GetOpenStackControlPlane(ctx, namespace, name)
+		if err != nil {
+			return nil, newStructuredError(
+				ErrorCodeK8sAPIError,
+				fmt.Sprintf("Failed to get OpenStackControlPlane '%s' in namespace '%s': %v", name, namespace, err),
+				"KubernetesAPIError",
+			)
+		}
+	} else {
+		// Auto-discover: List all OpenStackControlPlane CRs and use the first one
+		controlPlanes, err := k8sClient.ListOpenStackControlPlanes(ctx, namespace)
+		if err != nil {
+			return nil, newStructuredError(
+				ErrorCodeK8sAPIError,
+				fmt.Sprintf("Failed to list OpenStackControlPlanes in namespace '%s': %v", namespace, err),
+				"KubernetesAPIError",
+			)
+		}
+
+		if len(controlPlanes) == 0 {
+			return nil, newStructuredError(
+				ErrorCodeNotFound,
+				fmt.Sprintf("No OpenStackControlPlane CR found in namespace '%s'", namespace),
+				"ResourceNotFound",
+			)
+		}
+
+		controlPlane = controlPlanes[0]
+	}
+
+	metadata, _ := controlPlane["metadata"].(map[string]interface{})
+	name, _ = metadata["name"].(string)
+
+	// Optional condition parameter: trace one condition, even when it is True
+	conditionArg, _ := request.GetArguments()["condition"].(string)
+
+	_, failing := objectConditionDetails(controlPlane)
+	traced := []ConditionDetail{}
+	if conditionArg != "" {
+		found := false
+		for _, cond := range controlPlaneConditions(controlPlane) {
+			if condType, _ := cond["type"].(string); condType == conditionArg {
+				detail := ConditionDetail{Type: condType}
+				detail.Status, _ = cond["status"].(string)
+				detail.Reason, _ = cond["reason"].(string)
+				detail.Message, _ = cond["message"].(string)
+				traced = append(traced, detail)
+				found = true
+			}
+		}
+		if !found {
+			return nil, newStructuredError(
+				ErrorCodeNotFound,
+				fmt.Sprintf("OpenStackControlPlane '%s' has no condition '%s'", name, conditionArg),
+				"ResourceNotFound",
+			)
+		}
+	} else {
+		// Ready only aggregates the other conditions
+		for _, cond := range failing {
+			if cond.Type != DefaultWaitCondition {
+				traced = append(traced, cond)
+			}
+		}
+	}
+
+	// Discover and list every OpenStack CR kind of the namespace
+	warnings := []string{}
+	objects := []map[string]interface{}{}
+	if len(traced) > 0 {
+		resources, err := k8sClient.DiscoverOpenStackResources()
+		if err != nil {
+			return nil, newStructuredError(
+				ErrorCodeK8sAPIError,
+				fmt.Sprintf("Failed to discover the *.openstack.org API groups: %v", err),
+				"KubernetesAPIError",
+			)
+		}
+		for _, resource := range resources {
+			items, err := k8sClient.ListResources(ctx, resource.GVR, namespace)
+			if err != nil {
+				// A resource that no longer exists means the cached discovery is stale
+				if apierrors.IsNotFound(err) {
+					k8sClient.InvalidateDiscovery()
+				}
+				warnings = append(warnings, fmt.Sprintf("Skipped %s: %v", resource.Kind, err))
+				continue
+			}
+			objects = append(objects, items...)
+		}
+	}
+	index := newOpenStackObjects(objects)
+
+	// Map each condition to the CRs of its service owned by the control plane
+	trees := []ControlPlaneConditionTree{}
+	rootCauses := []RootCause{}
+	for _, cond := range traced {
+		tree := ControlPlaneConditionTree{ConditionDetail: cond, Resources: []*ResourceNode{}}
+		conditionRootCauses := []RootCause{}
+		for _, child := range index.children[objectUID(controlPlane)] {
+			kind, _ := child["kind"].(string)
+			if !conditionMatchesKind(cond.Type, kind) {
+				continue
+			}
+			visited := map[string]bool{objectUID(controlPlane): true}
+			tree.Resources = append(tree.Resources, index.resourceTree(child, cond.Type, visited, &conditionRootCauses))
+		}
+
+		// Without a failing CR below it, the condition itself is the deepest failure
+		if len(conditionRootCauses) == 0 && cond.Status != client.ConditionTrue {
+			conditionRootCauses = append(conditionRootCauses, RootCause{
+				Path:              fmt.Sprintf("%s > OpenStackControlPlane/%s", cond.Type, name),
+				Kind:              "OpenStackControlPlane",
+				Name:              name,
+				FailingConditions: []ConditionDetail{cond},
+				UID:               objectUID(controlPlane),
+			})
+		}
+		rootCauses = append(rootCauses, conditionRootCauses...)
+		trees = append(trees, tree)
+	}
+
+	return &controlPlaneTrace{
+		name:       name,
+		traced:     traced,
+		trees:      trees,
+		rootCauses: rootCauses,
+		warnings:   warnings,
+	}, nil
+}
+
+// TraceControlPlaneConditionsHandler handles the trace_controlplane_conditions tool call
+func TraceControlPlaneConditionsHandler(k8sClient *client.K8sClient, settings Settings) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract parameters
+		namespace, ok := request.GetArguments()["namespace"].(string)
+		if !ok || namespace == "" {
+			namespace = settings.DefaultNamespace
+		}
+
+		trace, errResult := traceControlPlane(ctx, k8sClient, request, namespace)
+		if errResult != nil {
+			return errResult, nil
+		}
+
+		// Build response
+		response := map[string]interface{}{
+			"name":       trace.name,
+			"namespace":  namespace,
+			"conditions": trace.trees,
+			"rootCauses": trace.rootCauses,
+		}
+		if len(trace.warnings) > 0 {
+			response["warnings"] = trace.warnings
+		}
+
+		switch {
+		case len(trace.traced) == 0:
+			response["message"] = fmt.Sprintf("All conditions on OpenStackControlPlane '%s' are True.", trace.name)
+		case len(trace.rootCauses) == 0:
+			response["message"] = fmt.Sprintf("No failing CR found below %d conditions.", len(trace.traced))
+		default:
+			response["message"] = fmt.Sprintf("Traced %d conditions to %d deepest failing CRs, first: %s. Call diagnose_not_ready to find their failing pods.", len(trace.traced), len(trace.rootCauses), trace.rootCauses[0].Path)
+		}
+
+		// Convert response to JSON
+		jsonData, err := json.MarshalIndent(response, "", "  ")
+		if err != nil {
+			return newStructuredError(
+				ErrorCodeMarshalError,
+				fmt.Sprintf("Failed to marshal response: %v", err),
+				"MarshalError",
+			), nil
+		}
+
+		return mcp.NewToolResultText(string(jsonData)), nil
+	}
+}
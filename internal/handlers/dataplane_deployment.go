@@ -11,15 +11,15 @@ import (
 )
 
 // CreateDataplaneDeploymentHandler handles the create_dataplane_deployment tool call
-func CreateDataplaneDeploymentHandler(k8sClient *client.K8sClient) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func CreateDataplaneDeploymentHandler(k8sClient *client.K8sClient, settings Settings) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		// Extract parameters
-		namespace, ok := request.Params.Arguments["namespace"].(string)
+		namespace, ok := request.GetArguments()["namespace"].(string)
 		if !ok || namespace == "" {
-			namespace = DefaultNamespace
+			namespace = settings.DefaultNamespace
 		}
 
-		name, ok := request.Params.Arguments["name"].(string)
+		name, ok := request.GetArguments()["name"].(string)
 		if !ok || name == "" {
 			return newStructuredError(
 				ErrorCodeInvalidParameter,
@@ -35,13 +35,13 @@ func CreateDataplaneDeploymentHandler(k8sClient *client.K8sClient) func(ctx cont
 		spec := make(map[string]interface{})
 
 		// Check if a spec parameter was provided (new flexible approach)
-		if specParam, ok := request.Params.Arguments["spec"].(map[string]interface{}); ok {
+		if specParam, ok := request.GetArguments()["spec"].(map[string]interface{}); ok {
 			// Use the provided spec directly
 			spec = specParam
 		} else {
 			// Legacy approach: extract nodeSets and servicesOverride individually
 			// Extract nodeSets parameter (optional - if not provided, auto-discover all nodeSets)
-			nodeSetsRaw, ok := request.Params.Arguments["nodeSets"]
+			nodeSetsRaw, ok := request.GetArguments()["nodeSets"]
 
 			var nodeSets []string
 			if !ok {
@@ -104,7 +104,7 @@ func CreateDataplaneDeploymentHandler(k8sClient *client.K8sClient) func(ctx cont
 			spec["nodeSets"] = nodeSets
 
 			// Extract optional servicesOverride parameter
-			if servicesOverrideRaw, ok := request.Params.Arguments["servicesOverride"]; ok {
+			if servicesOverrideRaw, ok := request.GetArguments()["servicesOverride"]; ok {
 				servicesOverrideArray, ok := servicesOverrideRaw.([]interface{})
 				if !ok {
 					return newStructuredError(
@@ -139,9 +139,9 @@ func CreateDataplaneDeploymentHandler(k8sClient *client.K8sClient) func(ctx cont
 			), nil
 		}
 
-		// Set default deploymentRequeueTime to 1 if not provided
+		// Set default deploymentRequeueTime if not provided
 		if _, ok := spec["deploymentRequeueTime"]; !ok {
-			spec["deploymentRequeueTime"] = 1
+			spec["deploymentRequeueTime"] = settings.DeploymentRequeueTime
 		}
 
 		// Create the OpenStackDataplaneDeployment CR
@@ -163,15 +163,15 @@ func CreateDataplaneDeploymentHandler(k8sClient *client.K8sClient) func(ctx cont
 }
 
 // GetDataplaneDeploymentHandler handles the get_dataplane_deployment tool call
-func GetDataplaneDeploymentHandler(k8sClient *client.K8sClient) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func GetDataplaneDeploymentHandler(k8sClient *client.K8sClient, settings Settings) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		// Extract parameters
-		namespace, ok := request.Params.Arguments["namespace"].(string)
+		namespace, ok := request.GetArguments()["namespace"].(string)
 		if !ok || namespace == "" {
-			namespace = DefaultNamespace
+			namespace = settings.DefaultNamespace
 		}
 
-		name, ok := request.Params.Arguments["name"].(string)
+		name, ok := request.GetArguments()["name"].(string)
 		if !ok || name == "" {
 			return newStructuredError(
 				ErrorCodeInvalidParameter,
@@ -224,15 +224,15 @@ func GetDataplaneDeploymentHandler(k8sClient *client.K8sClient) func(ctx context
 }
 
 // CreateDataplaneDeploymentOVNHandler handles the create_dataplane_deployment_ovn tool call
-func CreateDataplaneDeploymentOVNHandler(k8sClient *client.K8sClient) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func CreateDataplaneDeploymentOVNHandler(k8sClient *client.K8sClient, settings Settings) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		// Extract parameters
-		namespace, ok := request.Params.Arguments["namespace"].(string)
+		namespace, ok := request.GetArguments()["namespace"].(string)
 		if !ok || namespace == "" {
-			namespace = DefaultNamespace
+			namespace = settings.DefaultNamespace
 		}
 
-		name, ok := request.Params.Arguments["name"].(string)
+		name, ok := request.GetArguments()["name"].(string)
 		if !ok || name == "" {
 			return newStructuredError(
 				ErrorCodeInvalidParameter,
@@ -276,8 +276,8 @@ func CreateDataplaneDeploymentOVNHandler(k8sClient *client.K8sClient) func(ctx c
 		// Set servicesOverride to ["ovn"]
 		spec["servicesOverride"] = []string{"ovn"}
 
-		// Set default deploymentRequeueTime to 1
-		spec["deploymentRequeueTime"] = 1
+		// Set default deploymentRequeueTime
+		spec["deploymentRequeueTime"] = settings.DeploymentRequeueTime
 
 		// Create the OpenStackDataplaneDeployment CR
 		err = k8sClient.CreateDataplaneDeployment(ctx, namespace, name, spec)
@@ -293,20 +293,26 @@ func CreateDataplaneDeploymentOVNHandler(k8sClient *client.K8sClient) func(ctx c
 		specJSON, _ := json.MarshalIndent(spec, "", "  ")
 		successMessage := fmt.Sprintf("Successfully created OpenStackDataplaneDeployment '%s' in namespace '%s' with servicesOverride=[ovn] and spec:\n%s", name, namespace, string(specJSON))
 
+		// Record the running deployment so get_resume_step and run_minor_update monitor it
+		message := fmt.Sprintf("Created OpenStackDataplaneDeployment '%s' with servicesOverride=[ovn]", name)
+		if warning := recordToolStep(ctx, k8sClient, namespace, nil, StepDeployOVNDataplane, StepStatusRunning, message, updateServiceOVN, name, "create_dataplane_deployment_ovn"); warning != "" {
+			successMessage += "\n\nWarning: " + warning
+		}
+
 		return mcp.NewToolResultText(successMessage), nil
 	}
 }
 
 // CreateDataplaneDeploymentUpdateHandler handles the create_dataplane_deployment_update tool call
-func CreateDataplaneDeploymentUpdateHandler(k8sClient *client.K8sClient) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func CreateDataplaneDeploymentUpdateHandler(k8sClient *client.K8sClient, settings Settings) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		// Extract parameters
-		namespace, ok := request.Params.Arguments["namespace"].(string)
+		namespace, ok := request.GetArguments()["namespace"].(string)
 		if !ok || namespace == "" {
-			namespace = DefaultNamespace
+			namespace = settings.DefaultNamespace
 		}
 
-		name, ok := request.Params.Arguments["name"].(string)
+		name, ok := request.GetArguments()["name"].(string)
 		if !ok || name == "" {
 			return newStructuredError(
 				ErrorCodeInvalidParameter,
@@ -350,8 +356,8 @@ func CreateDataplaneDeploymentUpdateHandler(k8sClient *client.K8sClient) func(ct
 		// Set servicesOverride to ["update"]
 		spec["servicesOverride"] = []string{"update"}
 
-		// Set default deploymentRequeueTime to 1
-		spec["deploymentRequeueTime"] = 1
+		// Set default deploymentRequeueTime
+		spec["deploymentRequeueTime"] = settings.DeploymentRequeueTime
 
 		// Create the OpenStackDataplaneDeployment CR
 		err = k8sClient.CreateDataplaneDeployment(ctx, namespace, name, spec)
@@ -367,17 +373,23 @@ func CreateDataplaneDeploymentUpdateHandler(k8sClient *client.K8sClient) func(ct
 		specJSON, _ := json.MarshalIndent(spec, "", "  ")
 		successMessage := fmt.Sprintf("Successfully created OpenStackDataplaneDeployment '%s' in namespace '%s' with servicesOverride=[update] and spec:\n%s", name, namespace, string(specJSON))
 
+		// Record the running deployment so get_resume_step and run_minor_update monitor it
+		message := fmt.Sprintf("Created OpenStackDataplaneDeployment '%s' with servicesOverride=[update]", name)
+		if warning := recordToolStep(ctx, k8sClient, namespace, nil, StepDeployDataplane, StepStatusRunning, message, updateServiceUpdate, name, "create_dataplane_deployment_update"); warning != "" {
+			successMessage += "\n\nWarning: " + warning
+		}
+
 		return mcp.NewToolResultText(successMessage), nil
 	}
 }
 
 // ListDataplaneDeploymentsHandler handles the list_dataplane_deployments tool call
-func ListDataplaneDeploymentsHandler(k8sClient *client.K8sClient) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func ListDataplaneDeploymentsHandler(k8sClient *client.K8sClient, settings Settings) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		// Extract parameters
-		namespace, ok := request.Params.Arguments["namespace"].(string)
+		namespace, ok := request.GetArguments()["namespace"].(string)
 		if !ok || namespace == "" {
-			namespace = DefaultNamespace
+			namespace = settings.DefaultNamespace
 		}
 
 		// List all OpenStackDataplaneDeployment CRs
@@ -10,12 +10,12 @@ import (
 )
 
 // ListDataplaneNodeSetsHandler handles the list_dataplane_nodesets tool call
-func ListDataplaneNodeSetsHandler(k8sClient *client.K8sClient) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func ListDataplaneNodeSetsHandler(k8sClient *client.K8sClient, settings Settings) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		// Extract parameters
-		namespace, ok := request.Params.Arguments["namespace"].(string)
+		namespace, ok := request.GetArguments()["namespace"].(string)
 		if !ok || namespace == "" {
-			namespace = DefaultNamespace
+			namespace = settings.DefaultNamespace
 		}
 
 		// List all OpenStackDataplaneNodeSet CRs
@@ -69,12 +69,12 @@ func ListDataplaneNodeSetsHandler(k8sClient *client.K8sClient) func(ctx context.
 }
 
 // VerifyDataplaneNodeSetsHandler handles the verify_openstack_dataplanenodesets tool call
-func VerifyDataplaneNodeSetsHandler(k8sClient *client.K8sClient) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func VerifyDataplaneNodeSetsHandler(k8sClient *client.K8sClient, settings Settings) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		// Extract parameters
-		namespace, ok := request.Params.Arguments["namespace"].(string)
+		namespace, ok := request.GetArguments()["namespace"].(string)
 		if !ok || namespace == "" {
-			namespace = DefaultNamespace
+			namespace = settings.DefaultNamespace
 		}
 
 		// Verify all NodeSets in the namespace
@@ -0,0 +1,394 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/dprince/openstack-k8s-mcp/internal/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// DefaultLogTailLines is the default number of log lines returned per failing container
+const DefaultLogTailLines = 20
+
+// problemConditions are workload conditions that report a problem when they
+// are True; every other workload condition reports a problem when it is not True
+var problemConditions = []string{"Failed", "FailureTarget", "ReplicaFailure", "Suspended"}
+
+// startingReasons are waiting reasons of containers that have not started yet
+// without having failed
+var startingReasons = []string{"ContainerCreating", "PodInitializing"}
+
+// ContainerDiagnosis is the state of a container of a failing pod
+type ContainerDiagnosis struct {
+	Name string `json:"name"`
+	// Init is set for init containers
+	Init         bool   `json:"init,omitempty"`
+	Ready        bool   `json:"ready"`
+	RestartCount int32  `json:"restartCount"`
+	State        string `json:"state"`
+	Reason       string `json:"reason,omitempty"`
+	Message      string `json:"message,omitempty"`
+	ExitCode     *int32 `json:"exitCode,omitempty"`
+	// LastTerminationReason and LastExitCode describe the previous instance of a restarted container
+	LastTerminationReason string `json:"lastTerminationReason,omitempty"`
+	LastExitCode          *int32 `json:"lastExitCode,omitempty"`
+	Failing               bool   `json:"failing"`
+	// Logs are the last log lines of a failing container; LogsPrevious is set
+	// when they come from the previous instance of a crashed container
+	Logs         []string `json:"logs,omitempty"`
+	LogsPrevious bool     `json:"logsPrevious,omitempty"`
+	LogsError    string   `json:"logsError,omitempty"`
+}
+
+// PodDiagnosis is a pod that is not ready
+type PodDiagnosis struct {
+	Name       string               `json:"name"`
+	Phase      string               `json:"phase"`
+	Reason     string               `json:"reason,omitempty"`
+	Message    string               `json:"message,omitempty"`
+	Containers []ContainerDiagnosis `json:"containers"`
+}
+
+// WorkloadDiagnosis is a Deployment, StatefulSet or Job owned by a failing CR
+type WorkloadDiagnosis struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+	// Replicas and ReadyReplicas are set for Deployments and StatefulSets
+	Replicas      *int64 `json:"replicas,omitempty"`
+	ReadyReplicas *int64 `json:"readyReplicas,omitempty"`
+	// Active, Succeeded and Failed are set for Jobs
+	Active    *int64 `json:"active,omitempty"`
+	Succeeded *int64 `json:"succeeded,omitempty"`
+	Failed    *int64 `json:"failed,omitempty"`
+	// FailingConditions are the conditions that report a problem
+	FailingConditions []ConditionDetail `json:"failingConditions,omitempty"`
+	// FailingPods are the pods that are not ready; HealthyPods counts the others
+	FailingPods []PodDiagnosis `json:"failingPods"`
+	HealthyPods int            `json:"healthyPods"`
+}
+
+// NotReadyDiagnosis is a deepest failing CR with the workloads it owns
+type NotReadyDiagnosis struct {
+	RootCause
+	Workloads []WorkloadDiagnosis `json:"workloads"`
+}
+
+// workloadKinds are the kinds owned by OpenStack CRs that run pods
+var workloadKinds = []string{"Deployment", "StatefulSet", "Job"}
+
+// workloadFailingConditions returns the conditions of a workload that report a problem
+func workloadFailingConditions(obj map[string]interface{}) []ConditionDetail {
+	status, _ := obj["status"].(map[string]interface{})
+	conditions, _ := status["conditions"].([]interface{})
+
+	failing := []ConditionDetail{}
+	for _, condInterface := range conditions {
+		cond, ok := condInterface.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		detail := ConditionDetail{}
+		detail.Type, _ = cond["type"].(string)
+		detail.Status, _ = cond["status"].(string)
+		detail.Reason, _ = cond["reason"].(string)
+		detail.Message, _ = cond["message"].(string)
+		if contains(problemConditions, detail.Type) == (detail.Status == client.ConditionTrue) {
+			failing = append(failing, detail)
+		}
+	}
+	return failing
+}
+
+// podReady reports whether the Ready condition of a pod is True
+func podReady(pod *corev1.Pod) bool {
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodReady {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// podFailing reports whether a pod is failed, or not ready without having completed
+func podFailing(pod *corev1.Pod) bool {
+	switch pod.Status.Phase {
+	case corev1.PodSucceeded:
+		return false
+	case corev1.PodFailed:
+		return true
+	}
+	return !podReady(pod)
+}
+
+// containerDiagnosis returns the state of a container and whether it is failing:
+// waiting for a reason other than starting, terminated with a non-zero exit
+// code, or running without being ready
+func containerDiagnosis(status corev1.ContainerStatus, init bool) ContainerDiagnosis {
+	diagnosis := ContainerDiagnosis{
+		Name:         status.Name,
+		Init:         init,
+		Ready:        status.Ready,
+		RestartCount: status.RestartCount,
+	}
+
+	switch {
+	case status.State.Waiting != nil:
+		diagnosis.State = "waiting"
+		diagnosis.Reason = status.State.Waiting.Reason
+		diagnosis.Message = status.State.Waiting.Message
+		diagnosis.Failing = !contains(startingReasons, diagnosis.Reason)
+	case status.State.Terminated != nil:
+		diagnosis.State = "terminated"
+		diagnosis.Reason = status.State.Terminated.Reason
+		diagnosis.Message = status.State.Terminated.Message
+		exitCode := status.State.Terminated.ExitCode
+		diagnosis.ExitCode = &exitCode
+		diagnosis.Failing = exitCode != 0
+	case status.State.Running != nil:
+		diagnosis.State = "running"
+		// Running init containers are still in progress
+		diagnosis.Failing = !init && !status.Ready
+	default:
+		diagnosis.State = "unknown"
+	}
+
+	if last := status.LastTerminationState.Terminated; last != nil {
+		diagnosis.LastTerminationReason = last.Reason
+		exitCode := last.ExitCode
+		diagnosis.LastExitCode = &exitCode
+	}
+	return diagnosis
+}
+
+// podDiagnosis returns the state of a failing pod and its containers, with the
+// last tailLines log lines of the failing containers
+func podDiagnosis(ctx context.Context, k8sClient *client.K8sClient, pod *corev1.Pod, tailLines int64) PodDiagnosis {
+	diagnosis := PodDiagnosis{
+		Name:       pod.Name,
+		Phase:      string(pod.Status.Phase),
+		Reason:     pod.Status.Reason,
+		Message:    pod.Status.Message,
+		Containers: []ContainerDiagnosis{},
+	}
+	// Pending pods report why they are not scheduled on the PodScheduled condition
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodScheduled && cond.Status != corev1.ConditionTrue && diagnosis.Reason == "" {
+			diagnosis.Reason = cond.Reason
+			diagnosis.Message = cond.Message
+		}
+	}
+
+	statuses := []ContainerDiagnosis{}
+	for _, status := range pod.Status.InitContainerStatuses {
+		statuses = append(statuses, containerDiagnosis(status, true))
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		statuses = append(statuses, containerDiagnosis(status, false))
+	}
+
+	for _, container := range statuses {
+		// A container that never ran has no logs
+		neverRan := container.State == "waiting" && container.RestartCount == 0
+		if container.Failing && tailLines > 0 && !neverRan {
+			// A crashed container is restarting: its last log is in the previous instance
+			previous := container.RestartCount > 0 && container.State != "running"
+			logs, err := k8sClient.GetPodLogs(ctx, pod.Namespace, pod.Name, container.Name, tailLines, previous)
+			if err != nil {
+				container.LogsError = err.Error()
+			} else {
+				container.Logs = strings.Split(strings.TrimRight(logs, "\n"), "\n")
+				container.LogsPrevious = previous
+			}
+		}
+		diagnosis.Containers = append(diagnosis.Containers, container)
+	}
+	return diagnosis
+}
+
+// workloadDiagnosis returns the status of a workload and its failing pods.
+// pods maps an owner UID to the pods it owns; a Deployment owns its pods
+// through its ReplicaSets.
+func workloadDiagnosis(ctx context.Context, k8sClient *client.K8sClient, workload map[string]interface{}, index *openStackObjects, pods map[string][]*corev1.Pod, tailLines int64) WorkloadDiagnosis {
+	metadata, _ := workload["metadata"].(map[string]interface{})
+	status, _ := workload["status"].(map[string]interface{})
+	diagnosis := WorkloadDiagnosis{FailingPods: []PodDiagnosis{}}
+	diagnosis.Kind, _ = workload["kind"].(string)
+	diagnosis.Name, _ = metadata["name"].(string)
+	diagnosis.FailingConditions = workloadFailingConditions(workload)
+
+	field := func(key string) *int64 {
+		if value, ok := int64Field(status, key); ok {
+			return &value
+		}
+		zero := int64(0)
+		return &zero
+	}
+	if diagnosis.Kind == "Job" {
+		diagnosis.Active, diagnosis.Succeeded, diagnosis.Failed = field("active"), field("succeeded"), field("failed")
+	} else {
+		diagnosis.Replicas, diagnosis.ReadyReplicas = field("replicas"), field("readyReplicas")
+	}
+
+	owners := []string{objectUID(workload)}
+	if diagnosis.Kind == "Deployment" {
+		for _, replicaSet := range index.children[objectUID(workload)] {
+			if kind, _ := replicaSet["kind"].(string); kind == "ReplicaSet" {
+				owners = append(owners, objectUID(replicaSet))
+			}
+		}
+	}
+	for _, owner := range owners {
+		for _, pod := range pods[owner] {
+			if !podFailing(pod) {
+				diagnosis.HealthyPods++
+				continue
+			}
+			diagnosis.FailingPods = append(diagnosis.FailingPods, podDiagnosis(ctx, k8sClient, pod, tailLines))
+		}
+	}
+	return diagnosis
+}
+
+// firstFailingContainer returns a description of the first failing container
+// of the diagnoses, or of the first failing pod without one
+func firstFailingContainer(diagnoses []NotReadyDiagnosis) string {
+	failingPod := ""
+	for _, diagnosis := range diagnoses {
+		for _, workload := range diagnosis.Workloads {
+			for _, pod := range workload.FailingPods {
+				for _, container := range pod.Containers {
+					if !container.Failing {
+						continue
+					}
+					state := container.State
+					if container.Reason != "" {
+						state = fmt.Sprintf("%s (%s)", state, container.Reason)
+					}
+					return fmt.Sprintf("container '%s' of pod '%s' is %s with %d restarts", container.Name, pod.Name, state, container.RestartCount)
+				}
+				if failingPod == "" {
+					failingPod = fmt.Sprintf("pod '%s' is %s", pod.Name, pod.Phase)
+					if pod.Reason != "" {
+						failingPod = fmt.Sprintf("%s (%s)", failingPod, pod.Reason)
+					}
+				}
+			}
+		}
+	}
+	return failingPod
+}
+
+// DiagnoseNotReadyHandler handles the diagnose_not_ready tool call
+func DiagnoseNotReadyHandler(k8sClient *client.K8sClient, settings Settings) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract parameters
+		namespace, ok := request.GetArguments()["namespace"].(string)
+		if !ok || namespace == "" {
+			namespace = settings.DefaultNamespace
+		}
+
+		// Optional tailLines parameter (default 20 lines, 0 disables logs)
+		tailLines := int64(DefaultLogTailLines)
+		if tailLinesVal, ok := request.GetArguments()["tailLines"].(float64); ok {
+			tailLines = int64(tailLinesVal)
+		}
+		if tailLines < 0 {
+			return newStructuredError(
+				ErrorCodeInvalidParameter,
+				"tailLines must not be negative",
+				"ParameterValidationError",
+			), nil
+		}
+
+		trace, errResult := traceControlPlane(ctx, k8sClient, request, namespace)
+		if errResult != nil {
+			return errResult, nil
+		}
+
+		diagnoses := []NotReadyDiagnosis{}
+		if len(trace.rootCauses) > 0 {
+			workloads, err := k8sClient.ListWorkloads(ctx, namespace)
+			if err != nil {
+				return newStructuredError(
+					ErrorCodeK8sAPIError,
+					fmt.Sprintf("Failed to list workloads in namespace '%s': %v", namespace, err),
+					"KubernetesAPIError",
+				), nil
+			}
+			podList, err := k8sClient.ListPods(ctx, namespace)
+			if err != nil {
+				return newStructuredError(
+					ErrorCodeK8sAPIError,
+					fmt.Sprintf("Failed to list pods in namespace '%s': %v", namespace, err),
+					"KubernetesAPIError",
+				), nil
+			}
+
+			index := newOpenStackObjects(workloads)
+			pods := map[string][]*corev1.Pod{}
+			for i := range podList {
+				for _, owner := range podList[i].OwnerReferences {
+					pods[string(owner.UID)] = append(pods[string(owner.UID)], &podList[i])
+				}
+			}
+
+			// A CR can be the root cause of several conditions: diagnose it once
+			diagnosed := map[string][]WorkloadDiagnosis{}
+			for _, rootCause := range trace.rootCauses {
+				workloadDiagnoses, ok := diagnosed[rootCause.UID]
+				if !ok {
+					workloadDiagnoses = []WorkloadDiagnosis{}
+					for _, workload := range index.children[rootCause.UID] {
+						if kind, _ := workload["kind"].(string); !contains(workloadKinds, kind) {
+							continue
+						}
+						workloadDiagnoses = append(workloadDiagnoses, workloadDiagnosis(ctx, k8sClient, workload, index, pods, tailLines))
+					}
+					diagnosed[rootCause.UID] = workloadDiagnoses
+				}
+				diagnoses = append(diagnoses, NotReadyDiagnosis{RootCause: rootCause, Workloads: workloadDiagnoses})
+			}
+		}
+
+		// Build response
+		response := map[string]interface{}{
+			"name":       trace.name,
+			"namespace":  namespace,
+			"tailLines":  tailLines,
+			"rootCauses": diagnoses,
+		}
+		if len(trace.warnings) > 0 {
+			response["warnings"] = trace.warnings
+		}
+
+		switch {
+		case len(trace.traced) == 0:
+			response["message"] = fmt.Sprintf("All conditions on OpenStackControlPlane '%s' are True.", trace.name)
+		case len(diagnoses) == 0:
+			response["message"] = fmt.Sprintf("No failing CR found below %d conditions.", len(trace.traced))
+		default:
+			first := diagnoses[0]
+			if failing := firstFailingContainer(diagnoses); failing != "" {
+				response["message"] = fmt.Sprintf("Found %d deepest failing CRs, first: %s. The first failing %s.", len(diagnoses), first.Path, failing)
+			} else {
+				response["message"] = fmt.Sprintf("Found %d deepest failing CRs, first: %s. None of them owns a failing pod; check their failing conditions.", len(diagnoses), first.Path)
+			}
+		}
+
+		// Convert response to JSON
+		jsonData, err := json.MarshalIndent(response, "", "  ")
+		if err != nil {
+			return newStructuredError(
+				ErrorCodeMarshalError,
+				fmt.Sprintf("Failed to marshal response: %v", err),
+				"MarshalError",
+			), nil
+		}
+
+		return mcp.NewToolResultText(string(jsonData)), nil
+	}
+}
@@ -0,0 +1,527 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dprince/openstack-k8s-mcp/internal/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	openstackv1beta1 "github.com/openstack-k8s-operators/openstack-operator/apis/core/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// DefaultMinorUpdateStepTimeout is the default timeout in seconds for each
+// wait of run_minor_update. Dataplane deployments can take well over the
+// default wait timeout.
+const DefaultMinorUpdateStepTimeout = 3600
+
+// Status of a run_minor_update step
+const (
+	StepStatusRunning   = "running"
+	StepStatusCompleted = "completed"
+	StepStatusSkipped   = "skipped"
+	StepStatusFailed    = "failed"
+)
+
+// Dataplane services deployed by the minor update, see Steps 5 and 8
+const (
+	updateServiceOVN    = "ovn"
+	updateServiceUpdate = "update"
+)
+
+// StepResult is the outcome of one runbook step run by run_minor_update
+type StepResult struct {
+	Step            int    `json:"step"`
+	Title           string `json:"title"`
+	Status          string `json:"status"`
+	Message         string `json:"message"`
+	DurationSeconds int    `json:"durationSeconds"`
+}
+
+// minorUpdate holds the state of one run_minor_update call while it moves
+// through the runbook steps
+type minorUpdate struct {
+	k8sClient     *client.K8sClient
+	namespace     string
+	name          string
+	targetVersion string
+	timeout       int
+	pollInterval  int
+	report        func(progress, total float64, message string)
+
+	// deploymentRequeueTime is set on the OpenStackDataplaneDeployments it creates
+	deploymentRequeueTime int
+
+	// record is persisted on the OpenStackVersion CR after every change
+	record *UpdateRecord
+}
+
+// stepError is a step failure reported with a structured error code and type
+type stepError struct {
+	code    string
+	errType string
+	message string
+}
+
+func (e *stepError) Error() string {
+	return e.message
+}
+
+// saveStep records the status of step
+func (u *minorUpdate) saveStep(ctx context.Context, step int, status, message string) error {
+	u.record.setStep(step, status, message)
+	return saveUpdateRecord(ctx, u.k8sClient, u.namespace, u.name, u.record)
+}
+
+// nextStep returns the step that follows step, or 0 after the last step
+func nextStep(step int) int {
+	if step >= StepUpdateComplete {
+		return 0
+	}
+	return step + 1
+}
+
+// runStep runs a single runbook step and returns a summary of what it did
+func (u *minorUpdate) runStep(ctx context.Context, step int) (string, error) {
+	switch step {
+	case StepPreUpgradeValidation:
+		return u.preUpgradeValidation(ctx)
+	case StepSetTargetVersion:
+		return u.setTargetVersion(ctx)
+	case StepMonitorOVNControlplane:
+		return u.waitVersionCondition(ctx, step, "MinorUpdateOVNControlplane")
+	case StepDeployOVNDataplane:
+		return u.ensureDeployment(ctx, updateServiceOVN)
+	case StepMonitorOVNDataplane:
+		return u.monitorDeployment(ctx, step, updateServiceOVN, "MinorUpdateOVNDataplane")
+	case StepMonitorControlplane:
+		return u.waitVersionCondition(ctx, step, "MinorUpdateControlplane")
+	case StepDeployDataplane:
+		return u.ensureDeployment(ctx, updateServiceUpdate)
+	case StepMonitorDataplane:
+		return u.monitorDeployment(ctx, step, updateServiceUpdate, "MinorUpdateDataplane")
+	case StepUpdateComplete:
+		return u.verifyUpdate(ctx)
+	}
+	return "", fmt.Errorf("%s cannot be run by run_minor_update", stepTitle(step))
+}
+
+// stepProgress reports progress within step as a fraction of the whole update
+func (u *minorUpdate) stepProgress(step int) func(client.WaitProgress) {
+	return func(progress client.WaitProgress) {
+		u.report(float64(step), StepUpdateComplete, fmt.Sprintf("%s: %s", stepTitle(step), progressMessage(progress)))
+	}
+}
+
+// preUpgradeValidation runs the validate_pre_upgrade checks and fails with
+// the reasons of the checks that did not pass
+func (u *minorUpdate) preUpgradeValidation(ctx context.Context) (string, error) {
+	osVersion, err := u.k8sClient.GetOpenStackVersion(ctx, u.namespace, u.name)
+	if err != nil {
+		return "", fmt.Errorf("failed to get OpenStackVersion '%s': %w", u.name, err)
+	}
+
+	checks := preUpgradeChecks(ctx, u.k8sClient, osVersion, u.targetVersion)
+	if failed := failedChecks(checks); len(failed) > 0 {
+		reasons := make([]string, len(failed))
+		for i, check := range failed {
+			reasons[i] = fmt.Sprintf("%s: %s", check.Name, check.Reason)
+		}
+		return "", fmt.Errorf("pre-upgrade checks failed: %s", strings.Join(reasons, "; "))
+	}
+
+	return fmt.Sprintf("All %d pre-upgrade checks passed for version '%s'", len(checks), u.targetVersion), nil
+}
+
+// setTargetVersion starts the update by patching targetVersion. It refuses
+// the same versions as update_openstack_version and only patches the CR as it
+// was read.
+func (u *minorUpdate) setTargetVersion(ctx context.Context) (string, error) {
+	current, err := u.k8sClient.GetOpenStackVersion(ctx, u.namespace, u.name)
+	if err != nil {
+		return "", fmt.Errorf("failed to get OpenStackVersion '%s': %w", u.name, err)
+	}
+
+	if failures := checkTargetVersion(current, u.targetVersion); len(failures) > 0 {
+		messages := make([]string, len(failures))
+		for i, failure := range failures {
+			messages[i] = failure.Message
+		}
+		code := ErrorCodeInvalidParameter
+		if failures[0].Type == VersionErrorInProgress {
+			code = ErrorCodeConditionNotMet
+		}
+		return "", &stepError{code: code, errType: failures[0].Type, message: strings.Join(messages, "; ")}
+	}
+
+	opts := client.PatchOptions{ResourceVersion: current.ResourceVersion}
+	osVersion, err := u.k8sClient.PatchOpenStackVersion(ctx, u.namespace, u.name, u.targetVersion, nil, opts)
+	if apierrors.IsConflict(err) {
+		return "", &stepError{
+			code:    ErrorCodeConflict,
+			errType: "ConflictError",
+			message: fmt.Sprintf("OpenStackVersion '%s' was modified after resourceVersion %s was read, so targetVersion was not changed", u.name, current.ResourceVersion),
+		}
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to patch OpenStackVersion '%s': %w", u.name, err)
+	}
+	return fmt.Sprintf("Set targetVersion of OpenStackVersion '%s' to '%s'", osVersion.Name, osVersion.Spec.TargetVersion), nil
+}
+
+// waitVersionCondition waits for conditionType to become True on the OpenStackVersion CR
+func (u *minorUpdate) waitVersionCondition(ctx context.Context, step int, conditionType string) (string, error) {
+	spec := client.WaitSpec{
+		Conditions: []client.ConditionExpectation{{Type: conditionType, Status: client.ConditionTrue}},
+	}
+	status, err := u.k8sClient.WaitForResourceConditions(ctx, client.KindOpenStackVersion, u.namespace, u.name, spec, u.timeout, u.pollInterval, u.stepProgress(step))
+	if err != nil {
+		return "", fmt.Errorf("failed to wait for condition '%s' on OpenStackVersion '%s': %w", conditionType, u.name, err)
+	}
+	if !status.Met {
+		return "", fmt.Errorf("condition '%s' on OpenStackVersion '%s' was not met: %s (reason: %s)", conditionType, u.name, status.Message, status.Reason)
+	}
+	return fmt.Sprintf("Condition '%s' is True on OpenStackVersion '%s'", conditionType, u.name), nil
+}
+
+// deploymentName returns the name of the dataplane deployment run for
+// service during the update to the target version: the recorded one, if any
+func (u *minorUpdate) deploymentName(service string) string {
+	if name, ok := u.record.Deployment(service); ok {
+		return name
+	}
+	return strings.ReplaceAll(fmt.Sprintf("minor-update-%s-%s", service, u.targetVersion), ".", "-")
+}
+
+// ensureDeployment creates the dataplane deployment for service on every
+// nodeSet. A deployment left by an earlier run is reused, so resuming does
+// not deploy the same service twice, unless it failed.
+func (u *minorUpdate) ensureDeployment(ctx context.Context, service string) (string, error) {
+	name := u.deploymentName(service)
+
+	existing, err := u.k8sClient.GetDataplaneDeployment(ctx, u.namespace, name)
+	if err == nil {
+		status, reason := readyCondition(existing)
+		if status != client.ConditionTrue && contains(DeploymentFailReasons, reason) {
+			return "", fmt.Errorf("OpenStackDataplaneDeployment '%s' from an earlier run failed (reason: %s); delete it so it can be created again", name, reason)
+		}
+		if _, ok := u.record.Deployment(service); !ok {
+			u.record.addDeployment(service, name)
+			if err := saveUpdateRecord(ctx, u.k8sClient, u.namespace, u.name, u.record); err != nil {
+				return "", err
+			}
+		}
+		return fmt.Sprintf("OpenStackDataplaneDeployment '%s' with servicesOverride=[%s] already exists and is reused", name, service), nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return "", fmt.Errorf("failed to get OpenStackDataplaneDeployment '%s': %w", name, err)
+	}
+
+	nodeSets, err := u.k8sClient.ListDataplaneNodeSets(ctx, u.namespace)
+	if err != nil {
+		return "", fmt.Errorf("failed to list OpenStackDataplaneNodeSets: %w", err)
+	}
+	if len(nodeSets) == 0 {
+		return "", fmt.Errorf("no OpenStackDataplaneNodeSets found in namespace '%s'", u.namespace)
+	}
+
+	nodeSetNames := make([]string, len(nodeSets))
+	for i, nodeSet := range nodeSets {
+		metadata := nodeSet["metadata"].(map[string]interface{})
+		nodeSetNames[i] = metadata["name"].(string)
+	}
+
+	spec := map[string]interface{}{
+		"nodeSets":              nodeSetNames,
+		"servicesOverride":      []string{service},
+		"deploymentRequeueTime": u.deploymentRequeueTime,
+	}
+	if err := u.k8sClient.CreateDataplaneDeployment(ctx, u.namespace, name, spec); err != nil {
+		return "", fmt.Errorf("failed to create OpenStackDataplaneDeployment '%s': %w", name, err)
+	}
+
+	// Record the deployment right away so a resumed update monitors it instead of creating another
+	u.record.addDeployment(service, name)
+	if err := saveUpdateRecord(ctx, u.k8sClient, u.namespace, u.name, u.record); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("Created OpenStackDataplaneDeployment '%s' with servicesOverride=[%s] for nodeSets %s", name, service, strings.Join(nodeSetNames, ", ")), nil
+}
+
+// monitorDeployment waits for the dataplane deployment of service to become
+// Ready, then for conditionType to become True on the OpenStackVersion CR
+func (u *minorUpdate) monitorDeployment(ctx context.Context, step int, service, conditionType string) (string, error) {
+	name := u.deploymentName(service)
+
+	spec := client.WaitSpec{
+		Conditions:  []client.ConditionExpectation{{Type: DefaultWaitCondition, Status: client.ConditionTrue}},
+		FailReasons: DeploymentFailReasons,
+	}
+	status, err := u.k8sClient.WaitForResourceConditions(ctx, client.KindOpenStackDataplaneDeployment, u.namespace, name, spec, u.timeout, u.pollInterval, u.stepProgress(step))
+	if err != nil {
+		return "", fmt.Errorf("failed to wait for OpenStackDataplaneDeployment '%s': %w", name, err)
+	}
+	if !status.Met {
+		return "", fmt.Errorf("OpenStackDataplaneDeployment '%s' did not become Ready: %s (reason: %s). Inspect it with get_dataplane_deployment.", name, status.Message, status.Reason)
+	}
+
+	message, err := u.waitVersionCondition(ctx, step, conditionType)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("OpenStackDataplaneDeployment '%s' is Ready. %s", name, message), nil
+}
+
+// verifyUpdate checks that the target version is deployed and everything is ready
+func (u *minorUpdate) verifyUpdate(ctx context.Context) (string, error) {
+	osVersion, err := u.k8sClient.GetOpenStackVersion(ctx, u.namespace, u.name)
+	if err != nil {
+		return "", fmt.Errorf("failed to get OpenStackVersion '%s': %w", u.name, err)
+	}
+
+	deployedVersion := osVersion.Status.DeployedVersion
+	if deployedVersion == nil || *deployedVersion != u.targetVersion {
+		deployedVersionStr := "nil"
+		if deployedVersion != nil {
+			deployedVersionStr = *deployedVersion
+		}
+		return "", fmt.Errorf("deployedVersion is '%s', expected '%s'", deployedVersionStr, u.targetVersion)
+	}
+
+	notReady := []string{}
+	for _, cond := range osVersion.Status.Conditions {
+		if cond.Status != "True" {
+			notReady = append(notReady, string(cond.Type))
+		}
+	}
+	if len(notReady) > 0 {
+		return "", fmt.Errorf("OpenStackVersion '%s' has conditions that are not ready: %s", u.name, strings.Join(notReady, ", "))
+	}
+
+	controlPlanes, err := u.k8sClient.ListOpenStackControlPlanes(ctx, u.namespace)
+	if err != nil {
+		return "", fmt.Errorf("failed to list OpenStackControlPlanes: %w", err)
+	}
+	for _, controlPlane := range controlPlanes {
+		metadata, _ := controlPlane["metadata"].(map[string]interface{})
+		name, _ := metadata["name"].(string)
+
+		result, err := u.k8sClient.VerifyControlPlaneConditions(ctx, u.namespace, name)
+		if err != nil {
+			return "", fmt.Errorf("failed to verify OpenStackControlPlane '%s': %w", name, err)
+		}
+		if !result.AllReady {
+			return "", fmt.Errorf("OpenStackControlPlane '%s' is not ready after the update", name)
+		}
+	}
+
+	nodeSets, err := u.k8sClient.VerifyDataplaneNodeSetsConditions(ctx, u.namespace)
+	if err != nil {
+		return "", fmt.Errorf("failed to verify OpenStackDataplaneNodeSets: %w", err)
+	}
+	if !nodeSets.AllReady {
+		return "", fmt.Errorf("%d of %d OpenStackDataplaneNodeSets are not ready after the update", len(nodeSets.NotReadyNodeSets), nodeSets.TotalNodeSets)
+	}
+
+	return fmt.Sprintf("Version '%s' is deployed and the controlplane and all nodeSets are ready", u.targetVersion), nil
+}
+
+// RunMinorUpdateHandler handles the run_minor_update tool call. It runs runbook
+// steps 2-10 in order, starting from the step get_resume_step computes, and
+// stops at the first step that fails. Progress is persisted in the update
+// record on the OpenStackVersion CR.
+func RunMinorUpdateHandler(k8sClient *client.K8sClient, settings Settings) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract parameters
+		namespace, ok := request.GetArguments()["namespace"].(string)
+		if !ok || namespace == "" {
+			namespace = settings.DefaultNamespace
+		}
+
+		name, ok := request.GetArguments()["name"].(string)
+
+		var osVersion *openstackv1beta1.OpenStackVersion
+		var err error
+
+		if ok && name != "" {
+			osVersion, err = k8sClient.GetOpenStackVersion(ctx, namespace, name)
+			if err != nil {
+				return newStructuredError(
+					ErrorCodeK8sAPIError,
+					fmt.Sprintf("Failed to get OpenStackVersion '%s' in namespace '%s': %v", name, namespace, err),
+					"KubernetesAPIError",
+				), nil
+			}
+		} else {
+			versions, err := k8sClient.ListOpenStackVersions(ctx, namespace)
+			if err != nil {
+				return newStructuredError(
+					ErrorCodeK8sAPIError,
+					fmt.Sprintf("Failed to list OpenStackVersions in namespace '%s': %v", namespace, err),
+					"KubernetesAPIError",
+				), nil
+			}
+
+			if len(versions) == 0 {
+				return newStructuredError(
+					ErrorCodeNotFound,
+					fmt.Sprintf("No OpenStackVersion CR found in namespace '%s'", namespace),
+					"ResourceNotFound",
+				), nil
+			}
+
+			osVersion = &versions[0]
+		}
+
+		point := resumePoint(ctx, k8sClient, osVersion)
+		startStep, record := point.Step, point.Record
+		if startStep == StepDetermineResumePoint {
+			return newStructuredError(
+				ErrorCodeConditionNotMet,
+				fmt.Sprintf("Cannot tell whether a minor update is in progress: %s", point.Explanation),
+				"ResumePointUnknown",
+			), nil
+		}
+
+		// A new update needs a target version; an update in progress keeps its own
+		targetVersion, _ := request.GetArguments()["targetVersion"].(string)
+		if startStep == StepPreUpgradeValidation {
+			if targetVersion == "" {
+				return newStructuredError(
+					ErrorCodeInvalidParameter,
+					"targetVersion parameter is required to start a minor update",
+					"ParameterValidationError",
+				), nil
+			}
+		} else {
+			if targetVersion != "" && targetVersion != osVersion.Spec.TargetVersion {
+				return newStructuredError(
+					ErrorCodeInvalidParameter,
+					fmt.Sprintf("An update to '%s' is already in progress; targetVersion '%s' cannot be run until it completes", osVersion.Spec.TargetVersion, targetVersion),
+					"ParameterValidationError",
+				), nil
+			}
+			targetVersion = osVersion.Spec.TargetVersion
+		}
+
+		// Optional timeout parameter for each wait
+		timeout := DefaultMinorUpdateStepTimeout
+		if timeoutVal, ok := request.GetArguments()["timeout"].(float64); ok {
+			timeout = int(timeoutVal)
+		}
+
+		// Optional pollInterval parameter (default from settings, 5 seconds)
+		pollInterval := settings.WaitPollInterval
+		if pollIntervalVal, ok := request.GetArguments()["pollInterval"].(float64); ok {
+			pollInterval = int(pollIntervalVal)
+		}
+
+		update := &minorUpdate{
+			k8sClient:     k8sClient,
+			namespace:     osVersion.Namespace,
+			name:          osVersion.Name,
+			targetVersion: targetVersion,
+			timeout:       timeout,
+			pollInterval:  pollInterval,
+			report:        progressReporter(ctx, request),
+			record:        record,
+
+			deploymentRequeueTime: settings.DeploymentRequeueTime,
+		}
+		if !record.inProgress(targetVersion) {
+			update.record = newUpdateRecord(osVersion, targetVersion, triggeredBy(ctx, "run_minor_update"))
+		}
+
+		steps := []StepResult{}
+		for step := StepPreUpgradeValidation; step < startStep; step++ {
+			steps = append(steps, StepResult{
+				Step:    step,
+				Title:   stepTitle(step),
+				Status:  StepStatusSkipped,
+				Message: fmt.Sprintf("Already done, resuming at %s", stepTitle(startStep)),
+			})
+		}
+
+		// Run the steps in order until one fails
+		completed := true
+		failedStep := 0
+		var failure *stepError
+		for step := startStep; step != 0; step = nextStep(step) {
+			update.report(float64(step), StepUpdateComplete, fmt.Sprintf("Starting %s", stepTitle(step)))
+
+			started := time.Now()
+			message, err := "", update.saveStep(ctx, step, StepStatusRunning, "")
+			if err == nil {
+				message, err = update.runStep(ctx, step)
+			}
+			result := StepResult{
+				Step:            step,
+				Title:           stepTitle(step),
+				Status:          StepStatusCompleted,
+				Message:         message,
+				DurationSeconds: int(time.Since(started).Seconds()),
+			}
+			if err != nil {
+				result.Status = StepStatusFailed
+				result.Message = err.Error()
+			}
+			if saveErr := update.saveStep(ctx, step, result.Status, result.Message); saveErr != nil && err == nil {
+				err = saveErr
+				result.Status = StepStatusFailed
+				result.Message = fmt.Sprintf("%s, but %v", message, saveErr)
+			}
+			steps = append(steps, result)
+
+			if err != nil {
+				completed = false
+				failedStep = step
+				errors.As(err, &failure)
+				break
+			}
+		}
+
+		// Build response
+		response := map[string]interface{}{
+			"name":              update.name,
+			"namespace":         update.namespace,
+			"targetVersion":     targetVersion,
+			"startStep":         startStep,
+			"resumeSource":      point.Source,
+			"resumeExplanation": point.Explanation,
+			"completed":         completed,
+			"steps":             steps,
+			"updateRecord":      update.record,
+		}
+
+		if completed {
+			response["message"] = fmt.Sprintf("Minor update to '%s' completed", targetVersion)
+		} else {
+			response["failedStep"] = failedStep
+			if failure != nil {
+				response["code"] = failure.code
+				response["type"] = failure.errType
+			}
+			response["resumePrompt"] = StepPromptName(failedStep)
+			response["message"] = fmt.Sprintf("Minor update to '%s' stopped at %s. Fix the problem and call run_minor_update again to resume.", targetVersion, stepTitle(failedStep))
+		}
+
+		// Convert response to JSON
+		jsonData, err := json.MarshalIndent(response, "", "  ")
+		if err != nil {
+			return newStructuredError(
+				ErrorCodeMarshalError,
+				fmt.Sprintf("Failed to marshal response: %v", err),
+				"MarshalError",
+			), nil
+		}
+
+		result := mcp.NewToolResultText(string(jsonData))
+		result.IsError = !completed
+		return result, nil
+	}
+}
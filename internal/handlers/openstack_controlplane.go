@@ -10,15 +10,15 @@ import (
 )
 
 // GetOpenStackControlPlaneHandler handles the get_openstack_controlplane tool call
-func GetOpenStackControlPlaneHandler(k8sClient *client.K8sClient) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func GetOpenStackControlPlaneHandler(k8sClient *client.K8sClient, settings Settings) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		// Extract parameters
-		namespace, ok := request.Params.Arguments["namespace"].(string)
+		namespace, ok := request.GetArguments()["namespace"].(string)
 		if !ok || namespace == "" {
-			namespace = DefaultNamespace
+			namespace = settings.DefaultNamespace
 		}
 
-		name, ok := request.Params.Arguments["name"].(string)
+		name, ok := request.GetArguments()["name"].(string)
 
 		var controlPlane map[string]interface{}
 		var err error
@@ -71,15 +71,15 @@ func GetOpenStackControlPlaneHandler(k8sClient *client.K8sClient) func(ctx conte
 }
 
 // VerifyOpenStackControlPlaneHandler handles the verify_openstack_controlplane tool call
-func VerifyOpenStackControlPlaneHandler(k8sClient *client.K8sClient) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func VerifyOpenStackControlPlaneHandler(k8sClient *client.K8sClient, settings Settings) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		// Extract parameters
-		namespace, ok := request.Params.Arguments["namespace"].(string)
+		namespace, ok := request.GetArguments()["namespace"].(string)
 		if !ok || namespace == "" {
-			namespace = DefaultNamespace
+			namespace = settings.DefaultNamespace
 		}
 
-		name, ok := request.Params.Arguments["name"].(string)
+		name, ok := request.GetArguments()["name"].(string)
 
 		// If name is not provided, auto-discover the first OpenStackControlPlane in the namespace
 		if !ok || name == "" {
@@ -4,15 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/dprince/openstack-k8s-mcp/internal/client"
+	"github.com/dprince/openstack-k8s-mcp/internal/resume"
 	"github.com/mark3labs/mcp-go/mcp"
-	"github.com/mark3labs/mcp-go/server"
 	openstackv1beta1 "github.com/openstack-k8s-operators/openstack-operator/apis/core/v1beta1"
-)
-
-const (
-	DefaultNamespace = "openstack"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 )
 
 // Error codes for structured error responses
@@ -23,6 +21,8 @@ const (
 	ErrorCodeMarshalError     = "MARSHAL_ERROR"
 	ErrorCodeTimeout          = "TIMEOUT"
 	ErrorCodeConditionNotMet  = "CONDITION_NOT_MET"
+	ErrorCodeConflict         = "CONFLICT"
+	ErrorCodeInternal         = "INTERNAL_ERROR"
 )
 
 // StructuredError represents a structured error response for better LLM parsing
@@ -44,15 +44,15 @@ func newStructuredError(code, message, errType string) *mcp.CallToolResult {
 }
 
 // GetOpenStackVersionHandler handles the get_openstack_version tool call
-func GetOpenStackVersionHandler(k8sClient *client.K8sClient) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func GetOpenStackVersionHandler(k8sClient *client.K8sClient, settings Settings) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		// Extract parameters
-		namespace, ok := request.Params.Arguments["namespace"].(string)
+		namespace, ok := request.GetArguments()["namespace"].(string)
 		if !ok || namespace == "" {
-			namespace = DefaultNamespace
+			namespace = settings.DefaultNamespace
 		}
 
-		name, ok := request.Params.Arguments["name"].(string)
+		name, ok := request.GetArguments()["name"].(string)
 
 		var osVersion *openstackv1beta1.OpenStackVersion
 		var err error
@@ -81,6 +81,7 @@ func GetOpenStackVersionHandler(k8sClient *client.K8sClient) func(ctx context.Co
 		response := map[string]interface{}{
 			"name":             osVersion.Name,
 			"namespace":        osVersion.Namespace,
+			"resourceVersion":  osVersion.ResourceVersion,
 			"targetVersion":    osVersion.Spec.TargetVersion,
 			"availableVersion": osVersion.Status.AvailableVersion,
 			"deployedVersion":  osVersion.Status.DeployedVersion,
@@ -108,10 +109,24 @@ func GetOpenStackVersionHandler(k8sClient *client.K8sClient) func(ctx context.Co
 		response["readyConditions"] = readyConditions
 		response["notReadyConditions"] = notReadyConditions
 
-		// Add optional detailed fields if present
-		// if osVersion.Status.ContainerImages != nil {
-		// 	response["containerImages"] = osVersion.Status.ContainerImages
-		// }
+		// Add the container images per service when requested
+		if includeImages, _ := request.GetArguments()["includeImages"].(bool); includeImages {
+			var services []string
+			if service, ok := request.GetArguments()["service"].(string); ok && service != "" {
+				for _, name := range strings.Split(service, ",") {
+					services = append(services, strings.TrimSpace(name))
+				}
+			}
+
+			images, warning, err := openStackVersionImages(osVersion, services)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to read container images: %v", err)), nil
+			}
+			response["containerImages"] = images
+			if warning != "" {
+				response["containerImagesWarning"] = warning
+			}
+		}
 
 		// Convert response to JSON
 		jsonData, err := json.MarshalIndent(response, "", "  ")
@@ -123,44 +138,139 @@ func GetOpenStackVersionHandler(k8sClient *client.K8sClient) func(ctx context.Co
 	}
 }
 
+// openStackVersionImages returns the deployed and target container images of
+// an OpenStackVersion per service. Target images are status.containerImages,
+// which the operator rolls out for spec.targetVersion. While an update is in
+// progress the deployed images are the defaults of deployedVersion with the
+// custom images tracked for it; otherwise they are the target images. The
+// returned warning explains when the deployed images are not known.
+func openStackVersionImages(osVersion *openstackv1beta1.OpenStackVersion, services []string) (map[string][]ServiceImage, string, error) {
+	rolledOut, err := statusImages(osVersion)
+	if err != nil {
+		return nil, "", err
+	}
+	custom, err := customImages(osVersion)
+	if err != nil {
+		return nil, "", err
+	}
+
+	deployed := rolledOut
+	warning := ""
+	if deployedVersion := osVersion.Status.DeployedVersion; deployedVersion != nil && *deployedVersion != osVersion.Spec.TargetVersion {
+		tracked, err := trackedCustomImages(osVersion, *deployedVersion)
+		if err != nil {
+			return nil, "", err
+		}
+		images, found, err := versionImages(osVersion, *deployedVersion, tracked)
+		if err != nil {
+			return nil, "", err
+		}
+		if found {
+			deployed = images
+		} else {
+			deployed = imageSet{}
+			warning = fmt.Sprintf("No container image defaults are recorded for deployedVersion '%s'; only target images are shown", *deployedVersion)
+		}
+	}
+
+	images := serviceImages(deployed, rolledOut, custom, services)
+	if len(services) > 0 && len(images) == 0 {
+		warning = fmt.Sprintf("No container images match service %v", services)
+	}
+	return images, warning, nil
+}
+
 // UpdateOpenStackVersionHandler handles the update_openstack_version tool call
-func UpdateOpenStackVersionHandler(k8sClient *client.K8sClient) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func UpdateOpenStackVersionHandler(k8sClient *client.K8sClient, settings Settings) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		// Extract parameters
-		namespace, ok := request.Params.Arguments["namespace"].(string)
+		namespace, ok := request.GetArguments()["namespace"].(string)
 		if !ok || namespace == "" {
-			namespace = DefaultNamespace
+			namespace = settings.DefaultNamespace
 		}
 
-		// Auto-discover the first OpenStackVersion in the namespace
-		versions, err := k8sClient.ListOpenStackVersions(ctx, namespace)
-		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Failed to list OpenStackVersions in namespace '%s': %v", namespace, err)), nil
-		}
+		name, ok := request.GetArguments()["name"].(string)
 
-		if len(versions) == 0 {
-			return mcp.NewToolResultError(fmt.Sprintf("No OpenStackVersion CR found in namespace '%s'", namespace)), nil
-		}
+		var current *openstackv1beta1.OpenStackVersion
+		var err error
 
-		name := versions[0].Name
+		if ok && name != "" {
+			// Query the specific OpenStackVersion CR by name
+			current, err = k8sClient.GetOpenStackVersion(ctx, namespace, name)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to get OpenStackVersion '%s' in namespace '%s': %v", name, namespace, err)), nil
+			}
+		} else {
+			// Auto-discover the first OpenStackVersion in the namespace
+			versions, err := k8sClient.ListOpenStackVersions(ctx, namespace)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to list OpenStackVersions in namespace '%s': %v", namespace, err)), nil
+			}
 
-		targetVersion, ok := request.Params.Arguments["targetVersion"].(string)
+			if len(versions) == 0 {
+				return mcp.NewToolResultError(fmt.Sprintf("No OpenStackVersion CR found in namespace '%s'", namespace)), nil
+			}
+
+			current = &versions[0]
+		}
+		name = current.Name
+
+		targetVersion, ok := request.GetArguments()["targetVersion"].(string)
 		if !ok || targetVersion == "" {
 			return mcp.NewToolResultError("targetVersion parameter is required"), nil
 		}
 
+		// The patch only applies to the CR as it was read, or as the caller last saw it
+		resourceVersion, ok := request.GetArguments()["resourceVersion"].(string)
+		if !ok || resourceVersion == "" {
+			resourceVersion = current.ResourceVersion
+		}
+		dryRun, _ := request.GetArguments()["dryRun"].(bool)
+
+		// Refuse unknown versions, downgrades and a second update, unless forced
+		force, _ := request.GetArguments()["force"].(bool)
+		failures := checkTargetVersion(current, targetVersion)
+		if len(failures) > 0 && !force {
+			messages := make([]string, len(failures))
+			for i, failure := range failures {
+				messages[i] = failure.Message
+			}
+			code := ErrorCodeInvalidParameter
+			if failures[0].Type == VersionErrorInProgress {
+				code = ErrorCodeConditionNotMet
+			}
+			return newStructuredError(
+				code,
+				fmt.Sprintf("%s. Pass force=true to set it anyway.", strings.Join(messages, "; ")),
+				failures[0].Type,
+			), nil
+		}
+
 		// Extract optional customContainerImages parameter
 		var customContainerImages map[string]interface{}
-		if customImages, ok := request.Params.Arguments["customContainerImages"].(map[string]interface{}); ok {
+		if customImages, ok := request.GetArguments()["customContainerImages"].(map[string]interface{}); ok {
 			customContainerImages = customImages
 		}
 
 		// Patch the OpenStackVersion CR
-		osVersion, err := k8sClient.PatchOpenStackVersion(ctx, namespace, name, targetVersion, customContainerImages)
+		opts := client.PatchOptions{ResourceVersion: resourceVersion, DryRun: dryRun}
+		osVersion, err := k8sClient.PatchOpenStackVersion(ctx, namespace, name, targetVersion, customContainerImages, opts)
+		if apierrors.IsConflict(err) {
+			return newStructuredError(
+				ErrorCodeConflict,
+				fmt.Sprintf("OpenStackVersion '%s' in namespace '%s' was modified after resourceVersion %s was read, so targetVersion was not changed. Read it again with get_openstack_version and retry if the update is still wanted.", name, namespace, resourceVersion),
+				"ConflictError",
+			), nil
+		}
 		if err != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("Failed to patch OpenStackVersion '%s' in namespace '%s': %v", name, namespace, err)), nil
 		}
 
+		changes, err := specChanges(current.Spec, osVersion.Spec)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to compare OpenStackVersion specs: %v", err)), nil
+		}
+
 		// Build response with relevant fields
 		response := map[string]interface{}{
 			"name":      osVersion.Name,
@@ -172,6 +282,23 @@ func UpdateOpenStackVersionHandler(k8sClient *client.K8sClient) func(ctx context
 				"availableVersion": osVersion.Status.AvailableVersion,
 				"deployedVersion":  osVersion.Status.DeployedVersion,
 			},
+			"resourceVersion": osVersion.ResourceVersion,
+			"changes":         changes,
+		}
+
+		if len(failures) > 0 {
+			response["forced"] = failures
+		}
+
+		if dryRun {
+			response["dryRun"] = true
+			response["message"] = fmt.Sprintf("Dry run: the API server accepted the patch; %d spec fields would change. Nothing was persisted.", len(changes))
+		} else {
+			// Record that the update to targetVersion has started
+			message := fmt.Sprintf("Set targetVersion of OpenStackVersion '%s' to '%s'", osVersion.Name, targetVersion)
+			if warning := recordToolStep(ctx, k8sClient, namespace, osVersion, StepSetTargetVersion, StepStatusCompleted, message, "", "", "update_openstack_version"); warning != "" {
+				response["warning"] = warning
+			}
 		}
 
 		// Convert response to JSON
@@ -185,15 +312,15 @@ func UpdateOpenStackVersionHandler(k8sClient *client.K8sClient) func(ctx context
 }
 
 // WaitOpenStackVersionHandler handles the wait_openstack_version tool call
-func WaitOpenStackVersionHandler(k8sClient *client.K8sClient) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func WaitOpenStackVersionHandler(k8sClient *client.K8sClient, settings Settings) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		// Extract parameters
-		namespace, ok := request.Params.Arguments["namespace"].(string)
+		namespace, ok := request.GetArguments()["namespace"].(string)
 		if !ok || namespace == "" {
-			namespace = DefaultNamespace
+			namespace = settings.DefaultNamespace
 		}
 
-		name, ok := request.Params.Arguments["name"].(string)
+		name, ok := request.GetArguments()["name"].(string)
 
 		// If name is not provided, auto-discover the first OpenStackVersion in the namespace
 		if !ok || name == "" {
@@ -209,73 +336,25 @@ func WaitOpenStackVersionHandler(k8sClient *client.K8sClient) func(ctx context.C
 			name = versions[0].Name
 		}
 
-		conditionType, ok := request.Params.Arguments["condition"].(string)
-		if !ok || conditionType == "" {
-			return mcp.NewToolResultError("condition parameter is required"), nil
-		}
-
-		// Optional timeout parameter (default 600 seconds)
-		timeout := 600
-		if timeoutVal, ok := request.Params.Arguments["timeout"].(float64); ok {
-			timeout = int(timeoutVal)
-		}
-
-		// Optional pollInterval parameter (default 5 seconds)
-		pollInterval := 5
-		if pollIntervalVal, ok := request.Params.Arguments["pollInterval"].(float64); ok {
-			pollInterval = int(pollIntervalVal)
-		}
-
-		// Get the MCP server from context to send log notifications
-		mcpServer := server.ServerFromContext(ctx)
-
-		// Create a logging function that sends notifications to the client
-		logFunc := func(message string) {
-			if mcpServer != nil {
-				// Send a logging notification that will appear in the MCP client console
-				_ = mcpServer.SendNotificationToClient("notifications/message", map[string]interface{}{
-					"level":   "info",
-					"message": message,
-				})
-			}
-		}
-
-		// Wait for the condition
-		status, err := k8sClient.WaitForCondition(ctx, namespace, name, conditionType, timeout, pollInterval, logFunc)
+		spec, err := waitSpecArguments(request, "", nil)
 		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Failed to wait for condition '%s' on '%s' in namespace '%s': %v", conditionType, name, namespace, err)), nil
+			return waitSpecError(err), nil
 		}
 
-		// Build response
-		response := map[string]interface{}{
-			"name":      name,
-			"namespace": namespace,
-			"condition": conditionType,
-			"met":       status.Met,
-			"message":   status.Message,
-			"reason":    status.Reason,
-		}
-
-		// Convert response to JSON
-		jsonData, err := json.MarshalIndent(response, "", "  ")
-		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal response: %v", err)), nil
-		}
-
-		return mcp.NewToolResultText(string(jsonData)), nil
+		return waitForCondition(ctx, request, k8sClient, settings, client.KindOpenStackVersion, namespace, name, spec), nil
 	}
 }
 
 // GetResumeStepHandler determines which upgrade step to resume from
-func GetResumeStepHandler(k8sClient *client.K8sClient) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func GetResumeStepHandler(k8sClient *client.K8sClient, settings Settings) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		// Extract parameters
-		namespace, ok := request.Params.Arguments["namespace"].(string)
+		namespace, ok := request.GetArguments()["namespace"].(string)
 		if !ok || namespace == "" {
-			namespace = DefaultNamespace
+			namespace = settings.DefaultNamespace
 		}
 
-		name, ok := request.Params.Arguments["name"].(string)
+		name, ok := request.GetArguments()["name"].(string)
 
 		var osVersion *openstackv1beta1.OpenStackVersion
 		var err error
@@ -300,82 +379,33 @@ func GetResumeStepHandler(k8sClient *client.K8sClient) func(ctx context.Context,
 			osVersion = &versions[0]
 		}
 
+		// Determine the resume step from the update record, or the decision table
+		point := resumePoint(ctx, k8sClient, osVersion)
+
 		// Extract version information
 		targetVersion := osVersion.Spec.TargetVersion
 		availableVersion := osVersion.Status.AvailableVersion
 		deployedVersion := osVersion.Status.DeployedVersion
 
-		// Build notReadyConditions array
-		notReadyConditions := []string{}
-		for _, cond := range osVersion.Status.Conditions {
-			if cond.Status != "True" {
-				notReadyConditions = append(notReadyConditions, string(cond.Type))
-			}
-		}
-
-		// Determine the resume step based on the decision logic
-		var resumeStep int
-		var explanation string
-
-		// Check if upgrade is in progress
-		if availableVersion == nil || targetVersion != *availableVersion {
-			// Not in progress - should start from pre-upgrade validation
-			resumeStep = 2
-			availableVersionStr := "nil"
-			if availableVersion != nil {
-				availableVersionStr = *availableVersion
-			}
-			explanation = fmt.Sprintf("Upgrade not in progress (targetVersion='%s' != availableVersion='%s'). Start from Step 2: Pre-Upgrade Validation.", targetVersion, availableVersionStr)
-		} else if deployedVersion != nil && targetVersion == *deployedVersion && len(notReadyConditions) == 0 {
-			// All conditions ready and target equals deployed - upgrade is complete
-			resumeStep = 10
-			explanation = fmt.Sprintf("Upgrade complete (targetVersion='%s' == deployedVersion='%s' and all conditions ready). Jump to Step 10: Update Complete.", targetVersion, *deployedVersion)
-		} else {
-			// Upgrade is in progress - check notReadyConditions
-			upgradeInProgress := true
-
-			// Map conditions to steps based on the resume decision table
-			if contains(notReadyConditions, "MinorUpdateOVNControlplane") {
-				resumeStep = 4
-				explanation = "notReadyConditions contains 'MinorUpdateOVNControlplane'. Resume at Step 4: Monitor OVN Controlplane Deployment."
-			} else if contains(notReadyConditions, "MinorUpdateOVNDataplane") {
-				resumeStep = 5
-				explanation = "notReadyConditions contains 'MinorUpdateOVNDataplane'. Resume at Step 5: Deploy OVN on Dataplane."
-			} else if contains(notReadyConditions, "MinorUpdateControlplane") {
-				resumeStep = 7
-				explanation = "notReadyConditions contains 'MinorUpdateControlplane'. Resume at Step 7: Monitor Controlplane Update Completion."
-			} else if contains(notReadyConditions, "MinorUpdateDataplane") {
-				resumeStep = 8
-				explanation = "notReadyConditions contains 'MinorUpdateDataplane'. Resume at Step 8: Deploy Update on Dataplane."
-			} else if len(notReadyConditions) == 0 && deployedVersion != nil && targetVersion == *deployedVersion {
-				resumeStep = 10
-				explanation = "All conditions ready and targetVersion equals deployedVersion. Resume at Step 10: Update Complete."
-			} else {
-				// Fallback - continue with pre-upgrade validation
-				resumeStep = 2
-				explanation = fmt.Sprintf("Could not determine specific resume point from notReadyConditions=%v. Starting from Step 2: Pre-Upgrade Validation.", notReadyConditions)
-				upgradeInProgress = false
-			}
-
-			if upgradeInProgress {
-				availableVersionStr := "nil"
-				if availableVersion != nil {
-					availableVersionStr = *availableVersion
-				}
-				explanation = fmt.Sprintf("Upgrade in progress (targetVersion='%s' == availableVersion='%s'). %s", targetVersion, availableVersionStr, explanation)
-			}
-		}
-
 		// Build response
 		response := map[string]interface{}{
-			"name":               osVersion.Name,
-			"namespace":          osVersion.Namespace,
-			"targetVersion":      targetVersion,
-			"availableVersion":   availableVersion,
-			"deployedVersion":    deployedVersion,
-			"notReadyConditions": notReadyConditions,
-			"resumeStep":         resumeStep,
-			"explanation":        explanation,
+			"name":                 osVersion.Name,
+			"namespace":            osVersion.Namespace,
+			"targetVersion":        targetVersion,
+			"availableVersion":     availableVersion,
+			"deployedVersion":      deployedVersion,
+			"notReadyConditions":   point.Decision.NotReadyConditions,
+			"resumeStep":           point.Step,
+			"resumeStepName":       resume.StepName(point.Step),
+			"resumePrompt":         StepPromptName(point.Step),
+			"source":               point.Source,
+			"explanation":          point.Explanation,
+			"decisionTableVersion": point.Decision.TableVersion,
+			"matchedRules":         point.Decision.Path,
+		}
+
+		if point.Record != nil {
+			response["updateRecord"] = point.Record
 		}
 
 		// Convert response to JSON
@@ -388,6 +418,19 @@ func GetResumeStepHandler(k8sClient *client.K8sClient) func(ctx context.Context,
 	}
 }
 
+// resumeDecision determines the runbook step to resume a minor update from
+// the versions and conditions of the CR, using the resume decision table
+func resumeDecision(osVersion *openstackv1beta1.OpenStackVersion) resume.Decision {
+	decision, err := resume.Decide(resume.StateFromOpenStackVersion(osVersion))
+	if err != nil {
+		// The default table matches every state; fall back to the start of the runbook
+		decision.Step = StepDetermineResumePoint
+		decision.StepName = resume.StepName(decision.Step)
+		decision.Explanation = fmt.Sprintf("Could not determine the resume point: %v.", err)
+	}
+	return decision
+}
+
 // contains checks if a string slice contains a specific string
 func contains(slice []string, item string) bool {
 	for _, s := range slice {
@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dprince/openstack-k8s-mcp/internal/operations"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// AsyncHandler runs handler in the background as an operation when the call
+// sets the async argument, and returns the operation ID immediately
+func AsyncHandler(ops *operations.Manager, toolName string, handler HandlerFunc) HandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		async, _ := request.GetArguments()["async"].(bool)
+		if !async {
+			return handler(ctx, request)
+		}
+
+		// The progress token is only valid until this call returns; progress is
+		// recorded on the operation instead
+		request.Params.Meta = nil
+
+		op, err := ops.Start(ctx, sessionID(ctx), toolName, request.GetArguments(), func(ctx context.Context) (string, bool) {
+			result, err := handler(ctx, request)
+			if err != nil {
+				return err.Error(), true
+			}
+			return toolResultText(result), result.IsError
+		})
+		if err != nil {
+			return newStructuredError(
+				ErrorCodeInternal,
+				fmt.Sprintf("Failed to start operation: %v", err),
+				"OperationError",
+			), nil
+		}
+
+		return operationResult(map[string]interface{}{
+			"operationId": op.ID(),
+			"tool":        toolName,
+			"status":      operations.StatusRunning,
+			"message":     fmt.Sprintf("Started in the background. Poll get_operation with operationId '%s' for progress and the result.", op.ID()),
+		})
+	}
+}
+
+// sessionID returns the ID of the client session of ctx, which owns the
+// operations it starts
+func sessionID(ctx context.Context) string {
+	if session := server.ClientSessionFromContext(ctx); session != nil {
+		return session.SessionID()
+	}
+	return ""
+}
+
+// toolResultText joins the text contents of a tool result
+func toolResultText(result *mcp.CallToolResult) string {
+	texts := []string{}
+	for _, content := range result.Content {
+		if text, ok := content.(mcp.TextContent); ok {
+			texts = append(texts, text.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// operationResponse converts an operation snapshot to its JSON response.
+// The result is embedded as JSON when the tool returned JSON.
+func operationResponse(snapshot operations.Snapshot, includeResult bool) map[string]interface{} {
+	response := map[string]interface{}{
+		"operationId": snapshot.ID,
+		"tool":        snapshot.Tool,
+		"arguments":   snapshot.Arguments,
+		"status":      snapshot.Status,
+		"startedAt":   snapshot.StartedAt.UTC().Format(time.RFC3339),
+	}
+
+	end := time.Now()
+	if snapshot.Status != operations.StatusRunning {
+		end = snapshot.CompletedAt
+		response["completedAt"] = snapshot.CompletedAt.UTC().Format(time.RFC3339)
+	}
+	response["elapsedSeconds"] = int(end.Sub(snapshot.StartedAt).Seconds())
+
+	if snapshot.Progress != "" {
+		response["progress"] = snapshot.Progress
+	}
+
+	if includeResult && snapshot.Status != operations.StatusRunning {
+		if json.Valid([]byte(snapshot.Result)) {
+			response["result"] = json.RawMessage(snapshot.Result)
+		} else {
+			response["result"] = snapshot.Result
+		}
+	}
+
+	return response
+}
+
+// operationResult marshals an operations tool response
+func operationResult(response interface{}) (*mcp.CallToolResult, error) {
+	jsonData, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return newStructuredError(
+			ErrorCodeMarshalError,
+			fmt.Sprintf("Failed to marshal response: %v", err),
+			"MarshalError",
+		), nil
+	}
+
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+// operationError converts an operations error to a structured error
+func operationError(err error) *mcp.CallToolResult {
+	if errors.Is(err, operations.ErrNotFound) {
+		return newStructuredError(
+			ErrorCodeNotFound,
+			fmt.Sprintf("%v (completed operations are kept for a limited time)", err),
+			"ResourceNotFound",
+		)
+	}
+	return newStructuredError(
+		ErrorCodeInvalidParameter,
+		err.Error(),
+		"ParameterValidationError",
+	)
+}
+
+// operationIDArgument returns the required operationId argument
+func operationIDArgument(request mcp.CallToolRequest) (string, *mcp.CallToolResult) {
+	id, ok := request.GetArguments()["operationId"].(string)
+	if !ok || id == "" {
+		return "", newStructuredError(
+			ErrorCodeInvalidParameter,
+			"operationId parameter is required and must be a non-empty string",
+			"ParameterValidationError",
+		)
+	}
+	return id, nil
+}
+
+// GetOperationHandler handles the get_operation tool call
+func GetOperationHandler(ops *operations.Manager) HandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		id, errResult := operationIDArgument(request)
+		if errResult != nil {
+			return errResult, nil
+		}
+
+		op, err := ops.Get(id, sessionID(ctx))
+		if err != nil {
+			return operationError(err), nil
+		}
+
+		return operationResult(operationResponse(op.Snapshot(), true))
+	}
+}
+
+// ListOperationsHandler handles the list_operations tool call
+func ListOperationsHandler(ops *operations.Manager) HandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		status, _ := request.GetArguments()["status"].(string)
+
+		items := []map[string]interface{}{}
+		for _, op := range ops.List(sessionID(ctx)) {
+			snapshot := op.Snapshot()
+			if status != "" && string(snapshot.Status) != status {
+				continue
+			}
+			items = append(items, operationResponse(snapshot, false))
+		}
+
+		return operationResult(map[string]interface{}{
+			"operations":       items,
+			"retentionSeconds": int(ops.Retention().Seconds()),
+		})
+	}
+}
+
+// CancelOperationHandler handles the cancel_operation tool call
+func CancelOperationHandler(ops *operations.Manager) HandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		id, errResult := operationIDArgument(request)
+		if errResult != nil {
+			return errResult, nil
+		}
+
+		op, err := ops.Cancel(id, sessionID(ctx))
+		if err != nil {
+			return operationError(err), nil
+		}
+
+		response := operationResponse(op.Snapshot(), false)
+		response["message"] = "Cancellation requested. The operation status becomes 'cancelled' once it stops."
+		return operationResult(response)
+	}
+}
@@ -0,0 +1,305 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/dprince/openstack-k8s-mcp/internal/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	openstackv1beta1 "github.com/openstack-k8s-operators/openstack-operator/apis/core/v1beta1"
+)
+
+// Pre-upgrade checks, in the order they are reported
+const (
+	CheckControlPlaneReady        = "controlPlaneReady"
+	CheckNodeSetsReady            = "nodeSetsReady"
+	CheckNoRunningDeployments     = "noRunningDeployments"
+	CheckTargetVersionAvailable   = "targetVersionAvailable"
+	CheckTargetVersionNotDeployed = "targetVersionNotDeployed"
+)
+
+// PreUpgradeCheck is the outcome of one pre-upgrade check
+type PreUpgradeCheck struct {
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+	Reason string `json:"reason"`
+}
+
+// preUpgradeChecks runs every pre-upgrade check for an update of osVersion to
+// targetVersion. All checks run, so the caller gets the full checklist even
+// when an early check fails.
+func preUpgradeChecks(ctx context.Context, k8sClient *client.K8sClient, osVersion *openstackv1beta1.OpenStackVersion, targetVersion string) []PreUpgradeCheck {
+	namespace := osVersion.Namespace
+	return []PreUpgradeCheck{
+		checkControlPlaneReady(ctx, k8sClient, namespace),
+		checkNodeSetsReady(ctx, k8sClient, namespace),
+		checkNoRunningDeployments(ctx, k8sClient, namespace),
+		checkTargetVersionAvailable(osVersion, targetVersion),
+		checkTargetVersionNotDeployed(osVersion, targetVersion),
+	}
+}
+
+// failedChecks returns the checks that did not pass
+func failedChecks(checks []PreUpgradeCheck) []PreUpgradeCheck {
+	failed := []PreUpgradeCheck{}
+	for _, check := range checks {
+		if !check.Passed {
+			failed = append(failed, check)
+		}
+	}
+	return failed
+}
+
+// checkControlPlaneReady checks that every condition on the OpenStackControlPlane is True
+func checkControlPlaneReady(ctx context.Context, k8sClient *client.K8sClient, namespace string) PreUpgradeCheck {
+	check := PreUpgradeCheck{Name: CheckControlPlaneReady}
+
+	controlPlanes, err := k8sClient.ListOpenStackControlPlanes(ctx, namespace)
+	if err != nil {
+		check.Reason = fmt.Sprintf("Failed to list OpenStackControlPlanes: %v", err)
+		return check
+	}
+	if len(controlPlanes) == 0 {
+		check.Reason = fmt.Sprintf("No OpenStackControlPlane CR found in namespace '%s'", namespace)
+		return check
+	}
+	metadata, _ := controlPlanes[0]["metadata"].(map[string]interface{})
+	name, _ := metadata["name"].(string)
+
+	result, err := k8sClient.VerifyControlPlaneConditions(ctx, namespace, name)
+	if err != nil {
+		check.Reason = fmt.Sprintf("Failed to verify OpenStackControlPlane '%s': %v", name, err)
+		return check
+	}
+	if !result.AllReady {
+		notReady := make([]string, len(result.NotReadyConditions))
+		for i, cond := range result.NotReadyConditions {
+			notReady[i] = fmt.Sprintf("%s (%s)", cond["type"], cond["reason"])
+		}
+		check.Reason = fmt.Sprintf("OpenStackControlPlane '%s' has conditions that are not ready: %s", name, strings.Join(notReady, ", "))
+		return check
+	}
+
+	check.Passed = true
+	check.Reason = fmt.Sprintf("All %d conditions on OpenStackControlPlane '%s' are ready", result.TotalConditions, name)
+	return check
+}
+
+// checkNodeSetsReady checks that every condition on every OpenStackDataplaneNodeSet is True
+func checkNodeSetsReady(ctx context.Context, k8sClient *client.K8sClient, namespace string) PreUpgradeCheck {
+	check := PreUpgradeCheck{Name: CheckNodeSetsReady}
+
+	result, err := k8sClient.VerifyDataplaneNodeSetsConditions(ctx, namespace)
+	if err != nil {
+		check.Reason = fmt.Sprintf("Failed to verify OpenStackDataplaneNodeSets: %v", err)
+		return check
+	}
+	if !result.AllReady {
+		notReady := make([]string, len(result.NotReadyNodeSets))
+		for i, nodeSet := range result.NotReadyNodeSets {
+			conditions := make([]string, len(nodeSet.NotReadyConditions))
+			for j, cond := range nodeSet.NotReadyConditions {
+				conditions[j] = cond["type"]
+			}
+			notReady[i] = fmt.Sprintf("%s (%s)", nodeSet.Name, strings.Join(conditions, ", "))
+		}
+		check.Reason = fmt.Sprintf("%d of %d OpenStackDataplaneNodeSets are not ready: %s", len(result.NotReadyNodeSets), result.TotalNodeSets, strings.Join(notReady, "; "))
+		return check
+	}
+
+	check.Passed = true
+	check.Reason = fmt.Sprintf("All %d OpenStackDataplaneNodeSets are ready", result.TotalNodeSets)
+	return check
+}
+
+// checkNoRunningDeployments checks that no OpenStackDataplaneDeployment is still running
+func checkNoRunningDeployments(ctx context.Context, k8sClient *client.K8sClient, namespace string) PreUpgradeCheck {
+	check := PreUpgradeCheck{Name: CheckNoRunningDeployments}
+
+	running, err := runningDeployments(ctx, k8sClient, namespace)
+	if err != nil {
+		check.Reason = err.Error()
+		return check
+	}
+	if len(running) > 0 {
+		check.Reason = fmt.Sprintf("OpenStackDataplaneDeployments are still running: %s", strings.Join(running, ", "))
+		return check
+	}
+
+	check.Passed = true
+	check.Reason = "No OpenStackDataplaneDeployment is running"
+	return check
+}
+
+// checkTargetVersionAvailable checks that targetVersion is the availableVersion
+func checkTargetVersionAvailable(osVersion *openstackv1beta1.OpenStackVersion, targetVersion string) PreUpgradeCheck {
+	check := PreUpgradeCheck{Name: CheckTargetVersionAvailable}
+
+	availableVersion := osVersion.Status.AvailableVersion
+	if availableVersion == nil {
+		check.Reason = fmt.Sprintf("OpenStackVersion '%s' reports no availableVersion", osVersion.Name)
+		return check
+	}
+	if *availableVersion != targetVersion {
+		check.Reason = fmt.Sprintf("targetVersion '%s' is not the availableVersion '%s'", targetVersion, *availableVersion)
+		return check
+	}
+
+	check.Passed = true
+	check.Reason = fmt.Sprintf("targetVersion '%s' is the availableVersion", targetVersion)
+	return check
+}
+
+// checkTargetVersionNotDeployed checks that targetVersion is not deployed yet
+func checkTargetVersionNotDeployed(osVersion *openstackv1beta1.OpenStackVersion, targetVersion string) PreUpgradeCheck {
+	check := PreUpgradeCheck{Name: CheckTargetVersionNotDeployed}
+
+	deployedVersion := osVersion.Status.DeployedVersion
+	if deployedVersion != nil && *deployedVersion == targetVersion {
+		check.Reason = fmt.Sprintf("Version '%s' is already deployed, there is no update to run", targetVersion)
+		return check
+	}
+
+	check.Passed = true
+	if deployedVersion == nil {
+		check.Reason = "No version is deployed yet"
+	} else {
+		check.Reason = fmt.Sprintf("deployedVersion '%s' differs from targetVersion '%s'", *deployedVersion, targetVersion)
+	}
+	return check
+}
+
+// runningDeployments returns the dataplane deployments that are neither Ready
+// nor failed with one of the DeploymentFailReasons
+func runningDeployments(ctx context.Context, k8sClient *client.K8sClient, namespace string) ([]string, error) {
+	deployments, err := k8sClient.ListDataplaneDeployments(ctx, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list OpenStackDataplaneDeployments: %w", err)
+	}
+
+	running := []string{}
+	for _, deployment := range deployments {
+		metadata, _ := deployment["metadata"].(map[string]interface{})
+		name, _ := metadata["name"].(string)
+
+		status, reason := readyCondition(deployment)
+		if status == client.ConditionTrue || contains(DeploymentFailReasons, reason) {
+			continue
+		}
+		running = append(running, name)
+	}
+	return running, nil
+}
+
+// readyCondition returns the status and reason of the Ready condition of a CR
+func readyCondition(obj map[string]interface{}) (string, string) {
+	status, _ := obj["status"].(map[string]interface{})
+	conditions, _ := status["conditions"].([]interface{})
+	for _, condInterface := range conditions {
+		cond, ok := condInterface.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if condType, _ := cond["type"].(string); condType == DefaultWaitCondition {
+			condStatus, _ := cond["status"].(string)
+			condReason, _ := cond["reason"].(string)
+			return condStatus, condReason
+		}
+	}
+	return "", ""
+}
+
+// ValidatePreUpgradeHandler handles the validate_pre_upgrade tool call
+func ValidatePreUpgradeHandler(k8sClient *client.K8sClient, settings Settings) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract parameters
+		namespace, ok := request.GetArguments()["namespace"].(string)
+		if !ok || namespace == "" {
+			namespace = settings.DefaultNamespace
+		}
+
+		targetVersion, ok := request.GetArguments()["targetVersion"].(string)
+		if !ok || targetVersion == "" {
+			return newStructuredError(
+				ErrorCodeInvalidParameter,
+				"targetVersion parameter is required and must be a non-empty string",
+				"ParameterValidationError",
+			), nil
+		}
+
+		name, ok := request.GetArguments()["name"].(string)
+
+		var osVersion *openstackv1beta1.OpenStackVersion
+		var err error
+
+		if ok && name != "" {
+			// Query the specific OpenStackVersion CR by name
+			osVersion, err = k8sClient.GetOpenStackVersion(ctx, namespace, name)
+			if err != nil {
+				return newStructuredError(
+					ErrorCodeK8sAPIError,
+					fmt.Sprintf("Failed to get OpenStackVersion '%s' in namespace '%s': %v", name, namespace, err),
+					"KubernetesAPIError",
+				), nil
+			}
+		} else {
+			// Auto-discover: List all OpenStackVersion CRs and use the first one
+			versions, err := k8sClient.ListOpenStackVersions(ctx, namespace)
+			if err != nil {
+				return newStructuredError(
+					ErrorCodeK8sAPIError,
+					fmt.Sprintf("Failed to list OpenStackVersions in namespace '%s': %v", namespace, err),
+					"KubernetesAPIError",
+				), nil
+			}
+
+			if len(versions) == 0 {
+				return newStructuredError(
+					ErrorCodeNotFound,
+					fmt.Sprintf("No OpenStackVersion CR found in namespace '%s'", namespace),
+					"ResourceNotFound",
+				), nil
+			}
+
+			osVersion = &versions[0]
+		}
+
+		checks := preUpgradeChecks(ctx, k8sClient, osVersion, targetVersion)
+		failed := failedChecks(checks)
+
+		// Build response
+		response := map[string]interface{}{
+			"name":             osVersion.Name,
+			"namespace":        osVersion.Namespace,
+			"targetVersion":    targetVersion,
+			"availableVersion": osVersion.Status.AvailableVersion,
+			"deployedVersion":  osVersion.Status.DeployedVersion,
+			"passed":           len(failed) == 0,
+			"checks":           checks,
+		}
+
+		if len(failed) == 0 {
+			response["message"] = fmt.Sprintf("All %d pre-upgrade checks passed. The update to '%s' can start.", len(checks), targetVersion)
+		} else {
+			names := make([]string, len(failed))
+			for i, check := range failed {
+				names[i] = check.Name
+			}
+			response["message"] = fmt.Sprintf("%d of %d pre-upgrade checks failed: %s. Do not start the update.", len(failed), len(checks), strings.Join(names, ", "))
+		}
+
+		// Convert response to JSON
+		jsonData, err := json.MarshalIndent(response, "", "  ")
+		if err != nil {
+			return newStructuredError(
+				ErrorCodeMarshalError,
+				fmt.Sprintf("Failed to marshal response: %v", err),
+				"MarshalError",
+			), nil
+		}
+
+		return mcp.NewToolResultText(string(jsonData)), nil
+	}
+}
@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/dprince/openstack-k8s-mcp/internal/client"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Resource kinds used in openstack:// resource URIs
+const (
+	ResourceKindVersion      = "version"
+	ResourceKindControlPlane = "controlplane"
+	ResourceKindNodeSet      = "nodeset"
+	ResourceKindDeployment   = "deployment"
+)
+
+// ResourceKinds lists every resource kind in the order they are published
+var ResourceKinds = []string{
+	ResourceKindVersion,
+	ResourceKindControlPlane,
+	ResourceKindNodeSet,
+	ResourceKindDeployment,
+}
+
+// ResourceHandlerFunc is the signature shared by every resource handler
+type ResourceHandlerFunc = func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error)
+
+// ResourceURI builds the URI of an OpenStack CR resource. cluster is omitted when empty.
+func ResourceURI(cluster, namespace, kind, name string) string {
+	uri := fmt.Sprintf("openstack://%s/%s/%s", namespace, kind, name)
+	if cluster != "" {
+		uri += "?cluster=" + url.QueryEscape(cluster)
+	}
+	return uri
+}
+
+// resourceArgument returns a variable matched from the resource URI template
+func resourceArgument(request mcp.ReadResourceRequest, name string) string {
+	switch value := request.Params.Arguments[name].(type) {
+	case string:
+		return value
+	case []string:
+		if len(value) > 0 {
+			return value[0]
+		}
+	}
+	return ""
+}
+
+// OpenStackResourceHandler handles resources/read for openstack://{namespace}/{kind}/{name}
+func OpenStackResourceHandler(registry *client.Registry, kind string) ResourceHandlerFunc {
+	return func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		namespace := resourceArgument(request, "namespace")
+		name := resourceArgument(request, "name")
+		if namespace == "" || name == "" {
+			return nil, fmt.Errorf("resource URI '%s' must include a namespace and a name", request.Params.URI)
+		}
+
+		k8sClient, err := registry.Get(resourceArgument(request, "cluster"))
+		if err != nil {
+			return nil, err
+		}
+
+		obj, err := getOpenStackResource(ctx, k8sClient, kind, namespace, name)
+		if err != nil {
+			return nil, err
+		}
+
+		jsonData, err := json.MarshalIndent(obj, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal %s '%s' in namespace '%s': %w", kind, name, namespace, err)
+		}
+
+		return []mcp.ResourceContents{
+			mcp.TextResourceContents{
+				URI:      request.Params.URI,
+				MIMEType: "application/json",
+				Text:     string(jsonData),
+			},
+		}, nil
+	}
+}
+
+// getOpenStackResource fetches the CR of the given resource kind with managedFields removed
+func getOpenStackResource(ctx context.Context, k8sClient *client.K8sClient, kind, namespace, name string) (interface{}, error) {
+	var obj map[string]interface{}
+	var err error
+
+	switch kind {
+	case ResourceKindVersion:
+		osVersion, err := k8sClient.GetOpenStackVersion(ctx, namespace, name)
+		if err != nil {
+			return nil, err
+		}
+		osVersion.ManagedFields = nil
+		return osVersion, nil
+	case ResourceKindControlPlane:
+		obj, err = k8sClient.GetOpenStackControlPlane(ctx, namespace, name)
+	case ResourceKindNodeSet:
+		obj, err = k8sClient.GetDataplaneNodeSet(ctx, namespace, name)
+	case ResourceKindDeployment:
+		obj, err = k8sClient.GetDataplaneDeployment(ctx, namespace, name)
+	default:
+		return nil, fmt.Errorf("unknown resource kind '%s'", kind)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if metadata, ok := obj["metadata"].(map[string]interface{}); ok {
+		delete(metadata, "managedFields")
+	}
+
+	return obj, nil
+}
+
+// NamespaceResourceHandler handles resources/read for openstack://{namespace}.
+// It lists the URIs of every OpenStack CR in the namespace.
+func NamespaceResourceHandler(registry *client.Registry) ResourceHandlerFunc {
+	return func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		namespace := resourceArgument(request, "namespace")
+		if namespace == "" {
+			return nil, fmt.Errorf("resource URI '%s' must include a namespace", request.Params.URI)
+		}
+
+		cluster := resourceArgument(request, "cluster")
+		k8sClient, err := registry.Get(cluster)
+		if err != nil {
+			return nil, err
+		}
+
+		resources := []map[string]string{}
+		addResource := func(kind string, obj map[string]interface{}) {
+			metadata, _ := obj["metadata"].(map[string]interface{})
+			name, _ := metadata["name"].(string)
+			resources = append(resources, map[string]string{
+				"kind": kind,
+				"name": name,
+				"uri":  ResourceURI(cluster, namespace, kind, name),
+			})
+		}
+
+		versions, err := k8sClient.ListOpenStackVersions(ctx, namespace)
+		if err != nil {
+			return nil, err
+		}
+		for _, version := range versions {
+			resources = append(resources, map[string]string{
+				"kind": ResourceKindVersion,
+				"name": version.Name,
+				"uri":  ResourceURI(cluster, namespace, ResourceKindVersion, version.Name),
+			})
+		}
+
+		controlPlanes, err := k8sClient.ListOpenStackControlPlanes(ctx, namespace)
+		if err != nil {
+			return nil, err
+		}
+		for _, controlPlane := range controlPlanes {
+			addResource(ResourceKindControlPlane, controlPlane)
+		}
+
+		nodeSets, err := k8sClient.ListDataplaneNodeSets(ctx, namespace)
+		if err != nil {
+			return nil, err
+		}
+		for _, nodeSet := range nodeSets {
+			addResource(ResourceKindNodeSet, nodeSet)
+		}
+
+		deployments, err := k8sClient.ListDataplaneDeployments(ctx, namespace)
+		if err != nil {
+			return nil, err
+		}
+		for _, deployment := range deployments {
+			addResource(ResourceKindDeployment, deployment)
+		}
+
+		jsonData, err := json.MarshalIndent(map[string]interface{}{
+			"namespace": namespace,
+			"resources": resources,
+		}, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal resource index for namespace '%s': %w", namespace, err)
+		}
+
+		return []mcp.ResourceContents{
+			mcp.TextResourceContents{
+				URI:      request.Params.URI,
+				MIMEType: "application/json",
+				Text:     string(jsonData),
+			},
+		}, nil
+	}
+}
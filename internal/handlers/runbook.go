@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/dprince/openstack-k8s-mcp/internal/resume"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Runbook step numbers referenced by get_resume_step
+const (
+	StepDetermineResumePoint   = resume.StepDetermineResumePoint
+	StepPreUpgradeValidation   = resume.StepPreUpgradeValidation
+	StepSetTargetVersion       = resume.StepSetTargetVersion
+	StepMonitorOVNControlplane = resume.StepMonitorOVNControlplane
+	StepDeployOVNDataplane     = resume.StepDeployOVNDataplane
+	StepMonitorOVNDataplane    = resume.StepMonitorOVNDataplane
+	StepMonitorControlplane    = resume.StepMonitorControlplane
+	StepDeployDataplane        = resume.StepDeployDataplane
+	StepMonitorDataplane       = resume.StepMonitorDataplane
+	StepUpdateComplete         = resume.StepUpdateComplete
+)
+
+// RunbookPromptName is the name of the prompt holding the full minor update runbook
+const RunbookPromptName = "minor_update_runbook"
+
+// RunbookStep is one step of the minor update runbook. Instructions may
+// reference <namespace> and <targetVersion>, which are replaced with the
+// prompt arguments.
+type RunbookStep struct {
+	Number       int
+	Title        string
+	Description  string
+	Instructions string
+}
+
+// RunbookSteps is the minor update runbook, in order. Step numbers match the
+// resumeStep returned by get_resume_step.
+var RunbookSteps = []RunbookStep{
+	{
+		Number:      StepDetermineResumePoint,
+		Title:       "Determine Resume Point",
+		Description: "Find out whether a minor update is already in progress and which step to continue from.",
+		Instructions: `Call get_resume_step with namespace=<namespace>.
+- resumeStep is the step to continue from; explanation describes why and matchedRules lists the decision rules that chose it.
+- If resumeStep is 1, the OpenStackVersion has not reported an availableVersion yet: wait a minute and call get_resume_step again.
+- If resumeStep is 2, no update is in progress: continue with Step 2.
+- Otherwise skip directly to the returned step. Do not repeat earlier steps,
+  in particular do not set the target version again or create another dataplane deployment for a phase that is already running.`,
+	},
+	{
+		Number:      StepPreUpgradeValidation,
+		Title:       "Pre-Upgrade Validation",
+		Description: "Check that the deployment is healthy and that the target version is available before starting.",
+		Instructions: `Call validate_pre_upgrade with namespace=<namespace> and targetVersion=<targetVersion>.
+It checks that the controlplane and all nodeSets are ready, that no dataplane deployment is still running,
+that <targetVersion> equals availableVersion and that it differs from deployedVersion.
+If passed is false, report the reason of every failed check to the user and stop; do not start the update.`,
+	},
+	{
+		Number:      StepSetTargetVersion,
+		Title:       "Set Target Version",
+		Description: "Start the minor update by setting targetVersion on the OpenStackVersion CR.",
+		Instructions: `Call update_openstack_version with namespace=<namespace> and targetVersion=<targetVersion>.
+Confirm the response shows the new targetVersion. The operator now starts updating OVN on the controlplane.`,
+	},
+	{
+		Number:      StepMonitorOVNControlplane,
+		Title:       "Monitor OVN Controlplane Deployment",
+		Description: "Wait for the OVN controlplane services to be updated.",
+		Instructions: `Call wait_openstack_version with namespace=<namespace> and condition=MinorUpdateOVNControlplane.
+Repeat the call if it times out while the condition is still progressing.
+Continue once the condition is True.`,
+	},
+	{
+		Number:      StepDeployOVNDataplane,
+		Title:       "Deploy OVN on Dataplane",
+		Description: "Update OVN on every dataplane node.",
+		Instructions: `Call create_dataplane_deployment_ovn with namespace=<namespace>.
+This creates an OpenStackDataplaneDeployment with servicesOverride=['ovn'] for every nodeSet. Note the deployment name in the response.`,
+	},
+	{
+		Number:      StepMonitorOVNDataplane,
+		Title:       "Monitor OVN Dataplane Deployment",
+		Description: "Wait for the OVN dataplane deployment to finish.",
+		Instructions: `1. Call wait_dataplane_deployment with namespace=<namespace> and the deployment name from Step 5.
+   If it does not become Ready, call get_dataplane_deployment, report its conditions and deployment statuses to the user and stop.
+2. Call wait_openstack_version with namespace=<namespace> and condition=MinorUpdateOVNDataplane.
+Continue once the condition is True.`,
+	},
+	{
+		Number:      StepMonitorControlplane,
+		Title:       "Monitor Controlplane Update Completion",
+		Description: "Wait for the remaining controlplane services to be updated.",
+		Instructions: `Call wait_openstack_version with namespace=<namespace> and condition=MinorUpdateControlplane.
+Repeat the call if it times out while the condition is still progressing.
+Continue once the condition is True.`,
+	},
+	{
+		Number:      StepDeployDataplane,
+		Title:       "Deploy Update on Dataplane",
+		Description: "Update the remaining services on every dataplane node.",
+		Instructions: `Call create_dataplane_deployment_update with namespace=<namespace>.
+This creates an OpenStackDataplaneDeployment with servicesOverride=['update'] for every nodeSet. Note the deployment name in the response.`,
+	},
+	{
+		Number:      StepMonitorDataplane,
+		Title:       "Monitor Dataplane Update",
+		Description: "Wait for the dataplane update deployment to finish.",
+		Instructions: `1. Call wait_dataplane_deployment with namespace=<namespace> and the deployment name from Step 8.
+   If it does not become Ready, call get_dataplane_deployment, report its conditions and deployment statuses to the user and stop.
+2. Call wait_openstack_version with namespace=<namespace> and condition=MinorUpdateDataplane.
+Continue once the condition is True.`,
+	},
+	{
+		Number:      StepUpdateComplete,
+		Title:       "Update Complete",
+		Description: "Confirm the update finished and the deployment is healthy.",
+		Instructions: `1. Call get_openstack_version with namespace=<namespace>. deployedVersion must equal <targetVersion> and every condition must be True.
+2. Call verify_openstack_controlplane and verify_openstack_dataplanenodesets with namespace=<namespace>. allReady must be true for both.
+Report the deployed version and the final state to the user.`,
+	},
+}
+
+// StepPromptName returns the name of the prompt for a single runbook step
+func StepPromptName(step int) string {
+	return fmt.Sprintf("minor_update_step_%d", step)
+}
+
+// stepTitle returns "Step N: Title" for a runbook step
+func stepTitle(step int) string {
+	for _, s := range RunbookSteps {
+		if s.Number == step {
+			return fmt.Sprintf("Step %d: %s", s.Number, s.Title)
+		}
+	}
+	return fmt.Sprintf("Step %d", step)
+}
+
+// runbookReplacer substitutes the prompt arguments into step instructions
+func runbookReplacer(request mcp.GetPromptRequest, settings Settings) *strings.Replacer {
+	namespace := request.Params.Arguments["namespace"]
+	if namespace == "" {
+		namespace = settings.DefaultNamespace
+	}
+
+	targetVersion := request.Params.Arguments["targetVersion"]
+	if targetVersion == "" {
+		targetVersion = "the availableVersion reported by get_openstack_version"
+	}
+
+	return strings.NewReplacer(
+		"<namespace>", namespace,
+		"<targetVersion>", targetVersion,
+	)
+}
+
+// runbookPreamble applies to every prompt; it selects the cluster when one is given
+func runbookPreamble(request mcp.GetPromptRequest) string {
+	cluster := request.Params.Arguments["cluster"]
+	if cluster == "" {
+		return ""
+	}
+	return fmt.Sprintf("Pass cluster=%s to every tool call.\n\n", cluster)
+}
+
+// formatStep renders a runbook step with the prompt arguments substituted
+func formatStep(step RunbookStep, replacer *strings.Replacer) string {
+	return fmt.Sprintf("## Step %d: %s\n\n%s\n\n%s\n", step.Number, step.Title, step.Description, replacer.Replace(step.Instructions))
+}
+
+// RunbookPromptHandler handles prompts/get for the full minor update runbook
+func RunbookPromptHandler(settings Settings) func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	return func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		replacer := runbookReplacer(request, settings)
+
+		var text strings.Builder
+		text.WriteString("# OpenStack Minor Update Runbook\n\n")
+		text.WriteString("Perform a minor update of the OpenStack deployment by following these steps in order. ")
+		text.WriteString("Complete each step before moving to the next.\n\n")
+		text.WriteString(runbookPreamble(request))
+		for _, step := range RunbookSteps {
+			text.WriteString(formatStep(step, replacer))
+			text.WriteString("\n")
+		}
+
+		return mcp.NewGetPromptResult(
+			"OpenStack minor update runbook",
+			[]mcp.PromptMessage{
+				mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(text.String())),
+			},
+		), nil
+	}
+}
+
+// StepPromptHandler handles prompts/get for a single runbook step
+func StepPromptHandler(step RunbookStep, settings Settings) func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	return func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		var text strings.Builder
+		fmt.Fprintf(&text, "Perform step %d of %d of the OpenStack minor update runbook.\n\n", step.Number, len(RunbookSteps))
+		text.WriteString(runbookPreamble(request))
+		text.WriteString(formatStep(step, runbookReplacer(request, settings)))
+		if step.Number < len(RunbookSteps) {
+			fmt.Fprintf(&text, "\nWhen this step is done, continue with %s (prompt %s).\n", stepTitle(step.Number+1), StepPromptName(step.Number+1))
+		}
+
+		return mcp.NewGetPromptResult(
+			stepTitle(step.Number),
+			[]mcp.PromptMessage{
+				mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(text.String())),
+			},
+		), nil
+	}
+}
@@ -0,0 +1,14 @@
+package handlers
+
+// Settings holds the server-wide defaults applied by the handlers. They are
+// passed to every handler constructor along with the Kubernetes client.
+type Settings struct {
+	// DefaultNamespace is used when a tool call has no namespace argument
+	DefaultNamespace string
+	// WaitTimeout is the default wait timeout in seconds
+	WaitTimeout int
+	// WaitPollInterval is the default wait poll interval in seconds
+	WaitPollInterval int
+	// DeploymentRequeueTime is set on created OpenStackDataplaneDeployments
+	DeploymentRequeueTime int
+}
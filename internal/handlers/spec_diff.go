@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// SpecChange is a field that differs between two versions of a CR spec
+type SpecChange struct {
+	Path   string      `json:"path"`
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// specChanges returns the fields that differ between two specs, by JSON
+// path (e.g. "spec.customContainerImages.novaAPIImage"), sorted by path
+func specChanges(before, after interface{}) ([]SpecChange, error) {
+	beforeFields, err := flattenJSON(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := flattenJSON(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := []SpecChange{}
+	for path, value := range afterFields {
+		if old, ok := beforeFields[path]; !ok || !reflect.DeepEqual(old, value) {
+			changes = append(changes, SpecChange{Path: path, Before: beforeFields[path], After: value})
+		}
+	}
+	for path, value := range beforeFields {
+		if _, ok := afterFields[path]; !ok {
+			changes = append(changes, SpecChange{Path: path, Before: value})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, nil
+}
+
+// flattenJSON returns the leaf values of v's JSON form by path under "spec"
+func flattenJSON(v interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal spec: %w", err)
+	}
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal spec: %w", err)
+	}
+
+	fields := map[string]interface{}{}
+	var walk func(path string, value interface{})
+	walk = func(path string, value interface{}) {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			fields[path] = value
+			return
+		}
+		for key, child := range object {
+			walk(path+"."+key, child)
+		}
+	}
+	walk("spec", value)
+	return fields, nil
+}
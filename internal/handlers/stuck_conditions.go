@@ -0,0 +1,285 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dprince/openstack-k8s-mcp/internal/client"
+	"github.com/dprince/openstack-k8s-mcp/internal/resume"
+	"github.com/mark3labs/mcp-go/mcp"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// DefaultStuckThreshold is the default time in seconds a condition may stay
+// not True, or a status may lag behind the spec, before it is reported
+const DefaultStuckThreshold = 1800
+
+// Finding types returned by detect_stuck_conditions
+const (
+	FindingStuckCondition = "StuckCondition"
+	FindingStaleStatus    = "StaleStatus"
+)
+
+// normalConditions are conditions that stay False in normal operation, such
+// as MinorUpdateAvailable when no update is available
+var normalConditions = map[client.Kind][]string{
+	client.KindOpenStackVersion: {resume.ConditionMinorUpdateAvailable},
+}
+
+// StuckFinding is a condition that has not been True for longer than the
+// threshold, or a CR whose status has not caught up with its spec
+type StuckFinding struct {
+	Kind    client.Kind `json:"kind"`
+	Name    string      `json:"name"`
+	Finding string      `json:"finding"`
+	// Condition, Status and Reason are set for StuckCondition findings
+	Condition string `json:"condition,omitempty"`
+	Status    string `json:"status,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+	Message   string `json:"message"`
+	// Since is the lastTransitionTime of the condition, or for StaleStatus
+	// findings the last change of the spec
+	Since            *time.Time `json:"since,omitempty"`
+	AgeSeconds       int64      `json:"ageSeconds"`
+	Age              string     `json:"age"`
+	ThresholdSeconds int64      `json:"thresholdSeconds"`
+	// Generation and ObservedGeneration are set for StaleStatus findings
+	Generation         int64 `json:"generation,omitempty"`
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// int64Field returns an integer field of an unstructured object
+func int64Field(fields map[string]interface{}, key string) (int64, bool) {
+	switch v := fields[key].(type) {
+	case int64:
+		return v, true
+	case int:
+		return int64(v), true
+	case float64:
+		return int64(v), true
+	}
+	return 0, false
+}
+
+// lastSpecChange returns when the spec of an object last changed: the newest
+// managedFields entry touching the spec, falling back to the creation time
+func lastSpecChange(metadata map[string]interface{}) *time.Time {
+	var latest *time.Time
+	managedFields, _ := metadata["managedFields"].([]interface{})
+	for _, entryInterface := range managedFields {
+		entry, ok := entryInterface.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if subresource, _ := entry["subresource"].(string); subresource != "" {
+			continue
+		}
+		fieldsV1, _ := entry["fieldsV1"].(map[string]interface{})
+		if _, touchesSpec := fieldsV1["f:spec"]; !touchesSpec {
+			continue
+		}
+		value, _ := entry["time"].(string)
+		if changed := parseTimestamp(value); changed != nil && (latest == nil || changed.After(*latest)) {
+			latest = changed
+		}
+	}
+	if latest == nil {
+		created, _ := metadata["creationTimestamp"].(string)
+		return parseTimestamp(created)
+	}
+	return latest
+}
+
+// stuckFindings returns the conditions of obj that have not been True for
+// longer than threshold, and a StaleStatus finding when status.observedGeneration
+// has lagged behind metadata.generation for longer than threshold
+func stuckFindings(kind client.Kind, obj map[string]interface{}, threshold time.Duration, now time.Time) []StuckFinding {
+	metadata, _ := obj["metadata"].(map[string]interface{})
+	status, _ := obj["status"].(map[string]interface{})
+	name, _ := metadata["name"].(string)
+
+	finding := func(findingType string, since *time.Time) (StuckFinding, bool) {
+		if since == nil || now.Sub(*since) <= threshold {
+			return StuckFinding{}, false
+		}
+		age := int64(now.Sub(*since).Seconds())
+		return StuckFinding{
+			Kind:             kind,
+			Name:             name,
+			Finding:          findingType,
+			Since:            since,
+			AgeSeconds:       age,
+			Age:              formatSeconds(age),
+			ThresholdSeconds: int64(threshold.Seconds()),
+		}, true
+	}
+
+	findings := []StuckFinding{}
+	conditions, _ := status["conditions"].([]interface{})
+	for _, condInterface := range conditions {
+		cond, ok := condInterface.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		condType, _ := cond["type"].(string)
+		condStatus, _ := cond["status"].(string)
+		if condStatus == client.ConditionTrue || contains(normalConditions[kind], condType) {
+			continue
+		}
+
+		transition, _ := cond["lastTransitionTime"].(string)
+		stuck, ok := finding(FindingStuckCondition, parseTimestamp(transition))
+		if !ok {
+			continue
+		}
+		stuck.Condition = condType
+		stuck.Status = condStatus
+		stuck.Reason, _ = cond["reason"].(string)
+		stuck.Message, _ = cond["message"].(string)
+		findings = append(findings, stuck)
+	}
+
+	generation, hasGeneration := int64Field(metadata, "generation")
+	observedGeneration, hasObserved := int64Field(status, "observedGeneration")
+	if hasGeneration && hasObserved && observedGeneration < generation {
+		if stale, ok := finding(FindingStaleStatus, lastSpecChange(metadata)); ok {
+			stale.Generation = generation
+			stale.ObservedGeneration = observedGeneration
+			stale.Message = fmt.Sprintf("status.observedGeneration %d is behind metadata.generation %d: the operator has not reconciled the latest spec change, so the conditions may be out of date", observedGeneration, generation)
+			findings = append(findings, stale)
+		}
+	}
+
+	return findings
+}
+
+// DetectStuckConditionsHandler handles the detect_stuck_conditions tool call
+func DetectStuckConditionsHandler(k8sClient *client.K8sClient, settings Settings) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract parameters
+		namespace, ok := request.GetArguments()["namespace"].(string)
+		if !ok || namespace == "" {
+			namespace = settings.DefaultNamespace
+		}
+
+		// Optional thresholdSeconds parameter (default 1800 seconds)
+		thresholdSeconds := DefaultStuckThreshold
+		if thresholdVal, ok := request.GetArguments()["thresholdSeconds"].(float64); ok {
+			thresholdSeconds = int(thresholdVal)
+		}
+		if thresholdSeconds < 0 {
+			return newStructuredError(
+				ErrorCodeInvalidParameter,
+				"thresholdSeconds must not be negative",
+				"ParameterValidationError",
+			), nil
+		}
+		threshold := time.Duration(thresholdSeconds) * time.Second
+
+		// Optional kinds parameter (default: every OpenStack CR kind)
+		kinds := client.Kinds
+		kindNames, ok, err := stringArrayArgument(request, "kinds")
+		if err != nil {
+			return newStructuredError(ErrorCodeInvalidParameter, err.Error(), "ParameterValidationError"), nil
+		}
+		if ok {
+			kinds = []client.Kind{}
+			for _, kindName := range kindNames {
+				kind, found := client.Kind(""), false
+				for _, known := range client.Kinds {
+					if strings.EqualFold(string(known), kindName) {
+						kind, found = known, true
+					}
+				}
+				if !found {
+					return newStructuredError(
+						ErrorCodeInvalidParameter,
+						fmt.Sprintf("Unknown kind '%s' (must be one of %v)", kindName, client.Kinds),
+						"ParameterValidationError",
+					), nil
+				}
+				kinds = append(kinds, kind)
+			}
+		}
+
+		now := time.Now().UTC()
+		findings := []StuckFinding{}
+		checked := map[client.Kind]int{}
+		warnings := []string{}
+		for _, kind := range kinds {
+			objects, err := k8sClient.ListObjects(ctx, kind, namespace)
+			if err != nil {
+				// A CRD that is not installed is not a reason to fail the other kinds
+				if apierrors.IsNotFound(err) {
+					warnings = append(warnings, fmt.Sprintf("%s is not installed on the cluster", kind))
+					continue
+				}
+				return newStructuredError(
+					ErrorCodeK8sAPIError,
+					fmt.Sprintf("Failed to list %s CRs in namespace '%s': %v", kind, namespace, err),
+					"KubernetesAPIError",
+				), nil
+			}
+
+			checked[kind] = len(objects)
+			for _, obj := range objects {
+				findings = append(findings, stuckFindings(kind, obj, threshold, now)...)
+			}
+		}
+
+		// Oldest first
+		sort.SliceStable(findings, func(i, j int) bool {
+			return findings[i].AgeSeconds > findings[j].AgeSeconds
+		})
+
+		stuckConditions, staleObjects := 0, 0
+		for _, finding := range findings {
+			if finding.Finding == FindingStaleStatus {
+				staleObjects++
+			} else {
+				stuckConditions++
+			}
+		}
+
+		// Build response
+		response := map[string]interface{}{
+			"namespace":        namespace,
+			"thresholdSeconds": thresholdSeconds,
+			"threshold":        formatSeconds(int64(thresholdSeconds)),
+			"checked":          checked,
+			"stuckConditions":  stuckConditions,
+			"staleObjects":     staleObjects,
+			"findings":         findings,
+		}
+		if len(warnings) > 0 {
+			response["warnings"] = warnings
+		}
+
+		if len(findings) == 0 {
+			response["message"] = fmt.Sprintf("No condition has been not True for more than %s and every status is up to date.", formatSeconds(int64(thresholdSeconds)))
+		} else {
+			oldest := findings[0]
+			detail := fmt.Sprintf("%s on %s '%s', %s for %s", oldest.Condition, oldest.Kind, oldest.Name, oldest.Status, oldest.Age)
+			if oldest.Finding == FindingStaleStatus {
+				detail = fmt.Sprintf("the status of %s '%s', not reconciled for %s", oldest.Kind, oldest.Name, oldest.Age)
+			}
+			response["message"] = fmt.Sprintf("Found %d stuck conditions and %d stale statuses older than %s. The oldest is %s.", stuckConditions, staleObjects, formatSeconds(int64(thresholdSeconds)), detail)
+		}
+
+		// Convert response to JSON
+		jsonData, err := json.MarshalIndent(response, "", "  ")
+		if err != nil {
+			return newStructuredError(
+				ErrorCodeMarshalError,
+				fmt.Sprintf("Failed to marshal response: %v", err),
+				"MarshalError",
+			), nil
+		}
+
+		return mcp.NewToolResultText(string(jsonData)), nil
+	}
+}
@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/dprince/openstack-k8s-mcp/internal/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// resourceClientKinds maps each resource kind to the client Kind it watches
+var resourceClientKinds = map[string]client.Kind{
+	ResourceKindVersion:      client.KindOpenStackVersion,
+	ResourceKindControlPlane: client.KindOpenStackControlPlane,
+	ResourceKindNodeSet:      client.KindOpenStackDataplaneNodeSet,
+	ResourceKindDeployment:   client.KindOpenStackDataplaneDeployment,
+}
+
+// resourceRef identifies the CRs behind an openstack:// resource URI.
+// kind and name are empty for a namespace index URI.
+type resourceRef struct {
+	cluster   string
+	namespace string
+	kind      string
+	name      string
+}
+
+// parseResourceURI parses openstack://{namespace}[/{kind}/{name}][?cluster=...]
+func parseResourceURI(uri string) (*resourceRef, error) {
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("invalid resource URI '%s': %w", uri, err)
+	}
+
+	if parsed.Scheme != "openstack" || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid resource URI '%s': expected openstack://{namespace}", uri)
+	}
+
+	ref := &resourceRef{
+		cluster:   parsed.Query().Get("cluster"),
+		namespace: parsed.Host,
+	}
+
+	path := strings.Trim(parsed.Path, "/")
+	if path == "" {
+		return ref, nil
+	}
+
+	parts := strings.Split(path, "/")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid resource URI '%s': expected openstack://{namespace}/{kind}/{name}", uri)
+	}
+
+	if _, ok := resourceClientKinds[parts[0]]; !ok {
+		return nil, fmt.Errorf("invalid resource URI '%s': unknown resource kind '%s'", uri, parts[0])
+	}
+
+	ref.kind = parts[0]
+	ref.name = parts[1]
+	return ref, nil
+}
+
+// resourceWatch is the Kubernetes watch shared by every session subscribed to a URI
+type resourceWatch struct {
+	cancel   context.CancelFunc
+	sessions map[string]bool
+}
+
+// ResourceSubscriptions tracks resources/subscribe requests per session and
+// sends notifications/resources/updated when a Kubernetes watch reports a change
+type ResourceSubscriptions struct {
+	registry  *client.Registry
+	mcpServer *server.MCPServer
+
+	mu      sync.Mutex
+	watches map[string]*resourceWatch
+}
+
+// NewResourceSubscriptions creates an empty subscription tracker
+func NewResourceSubscriptions(registry *client.Registry, mcpServer *server.MCPServer) *ResourceSubscriptions {
+	return &ResourceSubscriptions{
+		registry:  registry,
+		mcpServer: mcpServer,
+		watches:   map[string]*resourceWatch{},
+	}
+}
+
+// resolve parses uri and returns the client of the cluster it names
+func (r *ResourceSubscriptions) resolve(uri string) (*resourceRef, *client.K8sClient, error) {
+	ref, err := parseResourceURI(uri)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	k8sClient, err := r.registry.Get(ref.cluster)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return ref, k8sClient, nil
+}
+
+// Validate returns an error if uri is not a valid resource URI or names an
+// unknown cluster, so a subscription can be rejected before it is acknowledged
+func (r *ResourceSubscriptions) Validate(uri string) error {
+	_, _, err := r.resolve(uri)
+	return err
+}
+
+// Subscribe subscribes the session to uri, starting a watch if it is the first subscriber
+func (r *ResourceSubscriptions) Subscribe(sessionID, uri string) error {
+	ref, k8sClient, err := r.resolve(uri)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if w, ok := r.watches[uri]; ok {
+		w.sessions[sessionID] = true
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r.watches[uri] = &resourceWatch{
+		cancel:   cancel,
+		sessions: map[string]bool{sessionID: true},
+	}
+
+	onChange := func() {
+		r.notify(uri)
+	}
+
+	// A single CR is watched by name; a namespace index watches every kind
+	kinds := []string{ref.kind}
+	if ref.kind == "" {
+		kinds = ResourceKinds
+	}
+
+	for _, kind := range kinds {
+		go func(kind client.Kind) {
+			if err := k8sClient.WatchObjects(ctx, kind, ref.namespace, ref.name, onChange); err != nil && ctx.Err() == nil {
+				log.Printf("Watch for resource '%s' (%s) stopped: %v", uri, kind, err)
+			}
+		}(resourceClientKinds[kind])
+	}
+
+	return nil
+}
+
+// Unsubscribe removes the session's subscription to uri, stopping the watch
+// when no subscribers remain
+func (r *ResourceSubscriptions) Unsubscribe(sessionID, uri string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.removeLocked(sessionID, uri)
+}
+
+// UnsubscribeSession removes every subscription held by the session
+func (r *ResourceSubscriptions) UnsubscribeSession(sessionID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for uri := range r.watches {
+		r.removeLocked(sessionID, uri)
+	}
+}
+
+// removeLocked removes one subscription; r.mu must be held
+func (r *ResourceSubscriptions) removeLocked(sessionID, uri string) {
+	w, ok := r.watches[uri]
+	if !ok {
+		return
+	}
+
+	delete(w.sessions, sessionID)
+	if len(w.sessions) == 0 {
+		w.cancel()
+		delete(r.watches, uri)
+	}
+}
+
+// notify sends notifications/resources/updated for uri to every subscribed session
+func (r *ResourceSubscriptions) notify(uri string) {
+	r.mu.Lock()
+	w, ok := r.watches[uri]
+	sessionIDs := []string{}
+	if ok {
+		for sessionID := range w.sessions {
+			sessionIDs = append(sessionIDs, sessionID)
+		}
+	}
+	r.mu.Unlock()
+
+	for _, sessionID := range sessionIDs {
+		_ = r.mcpServer.SendNotificationToSpecificClient(sessionID, mcp.MethodNotificationResourceUpdated, map[string]any{
+			"uri": uri,
+		})
+	}
+}
@@ -0,0 +1,510 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/dprince/openstack-k8s-mcp/internal/client"
+	"github.com/dprince/openstack-k8s-mcp/internal/resume"
+	"github.com/mark3labs/mcp-go/mcp"
+	openstackv1beta1 "github.com/openstack-k8s-operators/openstack-operator/apis/core/v1beta1"
+)
+
+// Status of a phase in the update history
+const (
+	PhaseStatusCompleted   = "completed"
+	PhaseStatusRunning     = "running"
+	PhaseStatusPending     = "pending"
+	PhaseStatusNotReported = "not-reported"
+)
+
+// Status of the version transition in the update history
+const (
+	TransitionCompleted  = "completed"
+	TransitionInProgress = "in-progress"
+)
+
+// updatePhase is a phase of a minor update, tracked by a condition on the
+// OpenStackVersion CR
+type updatePhase struct {
+	Name      string
+	Condition string
+	// Service is the dataplane service deployed during the phase, if any
+	Service string
+	// Milestones are conditions that become True while the phase runs
+	Milestones []string
+}
+
+// updatePhases are the phases of a minor update in the order the operator runs them
+var updatePhases = []updatePhase{
+	{Name: "ovn-controlplane", Condition: resume.ConditionMinorUpdateOVNControlplane},
+	{Name: "ovn-dataplane", Condition: resume.ConditionMinorUpdateOVNDataplane, Service: updateServiceOVN},
+	{Name: "controlplane", Condition: resume.ConditionMinorUpdateControlplane, Milestones: []string{
+		resume.ConditionMinorUpdateRabbitMQ,
+		resume.ConditionMinorUpdateMariaDB,
+		resume.ConditionMinorUpdateMemcached,
+		resume.ConditionMinorUpdateKeystone,
+	}},
+	{Name: "dataplane", Condition: resume.ConditionMinorUpdateDataplane, Service: updateServiceUpdate},
+}
+
+// versionCondition is the state of one OpenStackVersion condition
+type versionCondition struct {
+	Status  string
+	Message string
+	At      time.Time
+}
+
+// versionConditions returns the conditions of an OpenStackVersion CR by type
+func versionConditions(osVersion *openstackv1beta1.OpenStackVersion) map[string]versionCondition {
+	conditions := map[string]versionCondition{}
+	for _, cond := range osVersion.Status.Conditions {
+		conditions[string(cond.Type)] = versionCondition{
+			Status:  string(cond.Status),
+			Message: cond.Message,
+			At:      cond.LastTransitionTime.Time,
+		}
+	}
+	return conditions
+}
+
+// DeploymentHistory is the timing of an OpenStackDataplaneDeployment
+type DeploymentHistory struct {
+	Name             string     `json:"name"`
+	ServicesOverride []string   `json:"servicesOverride,omitempty"`
+	CreatedAt        *time.Time `json:"createdAt,omitempty"`
+	// CompletedAt is when the deployment became Ready
+	CompletedAt     *time.Time `json:"completedAt,omitempty"`
+	DurationSeconds *int64     `json:"durationSeconds,omitempty"`
+	Ready           string     `json:"ready"`
+	Reason          string     `json:"reason,omitempty"`
+}
+
+// deploymentHistory reads the timing of an OpenStackDataplaneDeployment
+func deploymentHistory(deployment map[string]interface{}) DeploymentHistory {
+	metadata, _ := deployment["metadata"].(map[string]interface{})
+	spec, _ := deployment["spec"].(map[string]interface{})
+	status, _ := deployment["status"].(map[string]interface{})
+
+	history := DeploymentHistory{}
+	history.Name, _ = metadata["name"].(string)
+	if created, ok := metadata["creationTimestamp"].(string); ok {
+		history.CreatedAt = parseTimestamp(created)
+	}
+	services, _ := spec["servicesOverride"].([]interface{})
+	for _, service := range services {
+		if service, ok := service.(string); ok {
+			history.ServicesOverride = append(history.ServicesOverride, service)
+		}
+	}
+
+	conditions, _ := status["conditions"].([]interface{})
+	for _, condInterface := range conditions {
+		cond, ok := condInterface.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if condType, _ := cond["type"].(string); condType != DefaultWaitCondition {
+			continue
+		}
+		history.Ready, _ = cond["status"].(string)
+		history.Reason, _ = cond["reason"].(string)
+		if transition, ok := cond["lastTransitionTime"].(string); ok && history.Ready == client.ConditionTrue {
+			history.CompletedAt = parseTimestamp(transition)
+		}
+	}
+	history.DurationSeconds = secondsBetween(history.CreatedAt, history.CompletedAt)
+	return history
+}
+
+// deploys reports whether the deployment deploys service
+func (d DeploymentHistory) deploys(service string) bool {
+	return contains(d.ServicesOverride, service)
+}
+
+// PhaseMilestone is a condition that became True during a phase
+type PhaseMilestone struct {
+	Condition   string     `json:"condition"`
+	Status      string     `json:"status"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+}
+
+// PhaseHistory is the timing of one phase of a minor update. A phase starts
+// when the previous one completes. For a running phase, DurationSeconds is
+// the time elapsed so far.
+type PhaseHistory struct {
+	Phase           string             `json:"phase"`
+	Condition       string             `json:"condition"`
+	Status          string             `json:"status"`
+	StartedAt       *time.Time         `json:"startedAt,omitempty"`
+	CompletedAt     *time.Time         `json:"completedAt,omitempty"`
+	DurationSeconds *int64             `json:"durationSeconds,omitempty"`
+	Milestones      []PhaseMilestone   `json:"milestones,omitempty"`
+	Deployment      *DeploymentHistory `json:"deployment,omitempty"`
+	Message         string             `json:"message,omitempty"`
+}
+
+// VersionTransition is the move from the previously deployed version to targetVersion
+type VersionTransition struct {
+	FromVersion string `json:"fromVersion,omitempty"`
+	// FromVersionSource is where FromVersion comes from: deployedVersion,
+	// record, or inferred from the versions with container image defaults
+	FromVersionSource string     `json:"fromVersionSource,omitempty"`
+	ToVersion         string     `json:"toVersion"`
+	Status            string     `json:"status"`
+	StartedAt         *time.Time `json:"startedAt,omitempty"`
+	// StartedAtSource is record, or conditions when the start is taken from
+	// the phases the operator reset when the update started
+	StartedAtSource string     `json:"startedAtSource,omitempty"`
+	CompletedAt     *time.Time `json:"completedAt,omitempty"`
+	DurationSeconds *int64     `json:"durationSeconds,omitempty"`
+}
+
+// UpdateHistory is the timeline of the last minor update of an OpenStackVersion CR
+type UpdateHistory struct {
+	Transition   VersionTransition `json:"transition"`
+	Phases       []PhaseHistory    `json:"phases"`
+	CurrentPhase string            `json:"currentPhase,omitempty"`
+	LongestPhase string            `json:"longestPhase,omitempty"`
+}
+
+// parseTimestamp parses an RFC 3339 timestamp of a Kubernetes object
+func parseTimestamp(value string) *time.Time {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil
+	}
+	return timestamp(t)
+}
+
+// timestamp returns t in UTC, or nil when t is zero
+func timestamp(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	t = t.UTC()
+	return &t
+}
+
+// secondsBetween returns the seconds from from to to, or nil when either is
+// unknown or to is before from
+func secondsBetween(from, to *time.Time) *int64 {
+	if from == nil || to == nil || to.Before(*from) {
+		return nil
+	}
+	seconds := int64(to.Sub(*from).Seconds())
+	return &seconds
+}
+
+// formatSeconds formats a duration in seconds, e.g. "1h2m3s"
+func formatSeconds(seconds int64) string {
+	return (time.Duration(seconds) * time.Second).String()
+}
+
+// previousVersion returns the version the update to targetVersion started
+// from, and where that comes from
+func previousVersion(osVersion *openstackv1beta1.OpenStackVersion, record *UpdateRecord) (string, string) {
+	target := osVersion.Spec.TargetVersion
+	if deployed := osVersion.Status.DeployedVersion; deployed != nil && *deployed != target {
+		return *deployed, "deployedVersion"
+	}
+	if record != nil && record.FromVersion != "" {
+		return record.FromVersion, "record"
+	}
+	if !isVersionLike(target) {
+		return "", ""
+	}
+	// availableVersions is newest first, so this is the newest older version
+	for _, version := range availableVersions(osVersion) {
+		if version.HasImageDefaults && compareVersions(version.Version, target) < 0 {
+			return version.Version, "inferred"
+		}
+	}
+	return "", ""
+}
+
+// updateStart returns when the update to targetVersion started, and where
+// that comes from. Without a record, the phases that are not complete yet
+// last transitioned when the operator started the update.
+func updateStart(conditions map[string]versionCondition, record *UpdateRecord) (*time.Time, string) {
+	if record != nil && !record.StartedAt.IsZero() {
+		return timestamp(record.StartedAt), "record"
+	}
+
+	var earliest *time.Time
+	for _, phase := range updatePhases {
+		for _, condType := range append([]string{phase.Condition}, phase.Milestones...) {
+			cond, ok := conditions[condType]
+			if !ok || cond.Status == client.ConditionTrue || cond.At.IsZero() {
+				continue
+			}
+			if earliest == nil || cond.At.Before(*earliest) {
+				earliest = timestamp(cond.At)
+			}
+		}
+	}
+	if earliest == nil {
+		return nil, ""
+	}
+	return earliest, "conditions"
+}
+
+// phaseDeployment returns the deployment of service for the update: the one
+// named in the update record, else the newest one deploying service created
+// after since, the start of the update
+func phaseDeployment(deployments []DeploymentHistory, record *UpdateRecord, service string, since *time.Time) *DeploymentHistory {
+	if record != nil {
+		if name, ok := record.Deployment(service); ok {
+			for i := range deployments {
+				if deployments[i].Name == name {
+					return &deployments[i]
+				}
+			}
+		}
+	}
+
+	var newest *DeploymentHistory
+	for i := range deployments {
+		deployment := &deployments[i]
+		if !deployment.deploys(service) || deployment.CreatedAt == nil {
+			continue
+		}
+		if since != nil && deployment.CreatedAt.Before(*since) {
+			continue
+		}
+		if newest == nil || deployment.CreatedAt.After(*newest.CreatedAt) {
+			newest = deployment
+		}
+	}
+	return newest
+}
+
+// updateHistory builds the timeline of the last minor update of osVersion
+// from its conditions, the dataplane deployments and the update record, which
+// is only used when it records the update to the current targetVersion
+func updateHistory(osVersion *openstackv1beta1.OpenStackVersion, record *UpdateRecord, deployments []DeploymentHistory, now time.Time) UpdateHistory {
+	target := osVersion.Spec.TargetVersion
+	if record != nil && record.TargetVersion != target {
+		record = nil
+	}
+	conditions := versionConditions(osVersion)
+	now = now.UTC()
+
+	history := UpdateHistory{Phases: []PhaseHistory{}}
+	transition := &history.Transition
+	transition.ToVersion = target
+	transition.FromVersion, transition.FromVersionSource = previousVersion(osVersion, record)
+	transition.StartedAt, transition.StartedAtSource = updateStart(conditions, record)
+	transition.Status = TransitionInProgress
+	if deployed := osVersion.Status.DeployedVersion; deployed != nil && *deployed == target {
+		transition.Status = TransitionCompleted
+	}
+
+	previousEnd := transition.StartedAt
+	var longest *int64
+	for _, phase := range updatePhases {
+		entry := PhaseHistory{Phase: phase.Name, Condition: phase.Condition}
+		if phase.Service != "" {
+			entry.Deployment = phaseDeployment(deployments, record, phase.Service, transition.StartedAt)
+		}
+		for _, condType := range phase.Milestones {
+			milestone := PhaseMilestone{Condition: condType, Status: PhaseStatusNotReported}
+			if cond, ok := conditions[condType]; ok {
+				milestone.Status = cond.Status
+				if cond.Status == client.ConditionTrue {
+					milestone.CompletedAt = timestamp(cond.At)
+				}
+			}
+			entry.Milestones = append(entry.Milestones, milestone)
+		}
+
+		entry.StartedAt = previousEnd
+		if entry.StartedAt == nil && entry.Deployment != nil {
+			entry.StartedAt = entry.Deployment.CreatedAt
+		}
+
+		cond, reported := conditions[phase.Condition]
+		switch {
+		case !reported:
+			entry.Status = PhaseStatusNotReported
+			entry.StartedAt = nil
+		case cond.Status == client.ConditionTrue:
+			entry.Status = PhaseStatusCompleted
+			entry.CompletedAt = timestamp(cond.At)
+			if entry.StartedAt != nil && entry.CompletedAt != nil && entry.CompletedAt.Before(*entry.StartedAt) {
+				entry.StartedAt = nil
+				entry.Message = "Completed before the update started; the operator did not run this phase again."
+			}
+			entry.DurationSeconds = secondsBetween(entry.StartedAt, entry.CompletedAt)
+			if entry.CompletedAt != nil {
+				previousEnd = entry.CompletedAt
+			}
+		case history.CurrentPhase == "":
+			entry.Status = PhaseStatusRunning
+			entry.DurationSeconds = secondsBetween(entry.StartedAt, &now)
+			entry.Message = cond.Message
+			if phase.Service != "" && entry.Deployment == nil {
+				entry.Message = fmt.Sprintf("Waiting for an OpenStackDataplaneDeployment with servicesOverride=[%s]. %s", phase.Service, cond.Message)
+			}
+			history.CurrentPhase = phase.Name
+		default:
+			entry.Status = PhaseStatusPending
+			entry.StartedAt = nil
+			entry.Message = cond.Message
+		}
+
+		if entry.DurationSeconds != nil && (longest == nil || *entry.DurationSeconds > *longest) {
+			longest = entry.DurationSeconds
+			history.LongestPhase = phase.Name
+		}
+		history.Phases = append(history.Phases, entry)
+	}
+
+	if transition.Status == TransitionCompleted {
+		for _, phase := range history.Phases {
+			if phase.CompletedAt != nil && (transition.CompletedAt == nil || phase.CompletedAt.After(*transition.CompletedAt)) {
+				transition.CompletedAt = phase.CompletedAt
+			}
+		}
+		transition.DurationSeconds = secondsBetween(transition.StartedAt, transition.CompletedAt)
+	} else {
+		transition.DurationSeconds = secondsBetween(transition.StartedAt, &now)
+	}
+
+	return history
+}
+
+// updateHistoryMessage summarizes an update history
+func updateHistoryMessage(history UpdateHistory) string {
+	transition := history.Transition
+	from := "an unknown version"
+	if transition.FromVersion != "" {
+		from = fmt.Sprintf("'%s'", transition.FromVersion)
+	}
+
+	message := fmt.Sprintf("Update from %s to '%s' ", from, transition.ToVersion)
+	switch {
+	case transition.Status == TransitionCompleted && transition.DurationSeconds != nil:
+		message += fmt.Sprintf("completed in %s.", formatSeconds(*transition.DurationSeconds))
+	case transition.Status == TransitionCompleted:
+		message += "completed; its start time is unknown."
+	case transition.DurationSeconds != nil:
+		message += fmt.Sprintf("has been running for %s.", formatSeconds(*transition.DurationSeconds))
+	default:
+		message += "is in progress; its start time is unknown."
+	}
+
+	for _, phase := range history.Phases {
+		if phase.Phase == history.CurrentPhase && phase.DurationSeconds != nil {
+			message += fmt.Sprintf(" Current phase: %s, running for %s.", phase.Phase, formatSeconds(*phase.DurationSeconds))
+		} else if phase.Phase == history.CurrentPhase {
+			message += fmt.Sprintf(" Current phase: %s.", phase.Phase)
+		}
+		if phase.Phase == history.LongestPhase && phase.Phase != history.CurrentPhase {
+			message += fmt.Sprintf(" Longest phase: %s (%s).", phase.Phase, formatSeconds(*phase.DurationSeconds))
+		}
+	}
+	return message
+}
+
+// GetUpdateHistoryHandler handles the get_update_history tool call
+func GetUpdateHistoryHandler(k8sClient *client.K8sClient, settings Settings) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract parameters
+		namespace, ok := request.GetArguments()["namespace"].(string)
+		if !ok || namespace == "" {
+			namespace = settings.DefaultNamespace
+		}
+
+		name, ok := request.GetArguments()["name"].(string)
+
+		var osVersion *openstackv1beta1.OpenStackVersion
+		var err error
+
+		if ok && name != "" {
+			// Query the specific OpenStackVersion CR by name
+			osVersion, err = k8sClient.GetOpenStackVersion(ctx, namespace, name)
+			if err != nil {
+				return newStructuredError(
+					ErrorCodeK8sAPIError,
+					fmt.Sprintf("Failed to get OpenStackVersion '%s' in namespace '%s': %v", name, namespace, err),
+					"KubernetesAPIError",
+				), nil
+			}
+		} else {
+			// Auto-discover: List all OpenStackVersion CRs and use the first one
+			versions, err := k8sClient.ListOpenStackVersions(ctx, namespace)
+			if err != nil {
+				return newStructuredError(
+					ErrorCodeK8sAPIError,
+					fmt.Sprintf("Failed to list OpenStackVersions in namespace '%s': %v", namespace, err),
+					"KubernetesAPIError",
+				), nil
+			}
+
+			if len(versions) == 0 {
+				return newStructuredError(
+					ErrorCodeNotFound,
+					fmt.Sprintf("No OpenStackVersion CR found in namespace '%s'", namespace),
+					"ResourceNotFound",
+				), nil
+			}
+
+			osVersion = &versions[0]
+		}
+
+		// The history is still useful without the record or the deployments
+		warnings := []string{}
+		record, err := readUpdateRecord(osVersion)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("Ignoring the update record: %v", err))
+		}
+
+		deployments := []DeploymentHistory{}
+		items, err := k8sClient.ListDataplaneDeployments(ctx, osVersion.Namespace)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("Dataplane phases lack deployment times: failed to list OpenStackDataplaneDeployments: %v", err))
+		}
+		for _, item := range items {
+			deployments = append(deployments, deploymentHistory(item))
+		}
+		sort.Slice(deployments, func(i, j int) bool { return deployments[i].Name < deployments[j].Name })
+
+		history := updateHistory(osVersion, record, deployments, time.Now())
+
+		// Build response
+		response := map[string]interface{}{
+			"name":            osVersion.Name,
+			"namespace":       osVersion.Namespace,
+			"targetVersion":   osVersion.Spec.TargetVersion,
+			"deployedVersion": osVersion.Status.DeployedVersion,
+			"transition":      history.Transition,
+			"phases":          history.Phases,
+			"message":         updateHistoryMessage(history),
+		}
+		if history.CurrentPhase != "" {
+			response["currentPhase"] = history.CurrentPhase
+		}
+		if history.LongestPhase != "" {
+			response["longestPhase"] = history.LongestPhase
+		}
+		if len(warnings) > 0 {
+			response["warnings"] = warnings
+		}
+
+		// Convert response to JSON
+		jsonData, err := json.MarshalIndent(response, "", "  ")
+		if err != nil {
+			return newStructuredError(
+				ErrorCodeMarshalError,
+				fmt.Sprintf("Failed to marshal response: %v", err),
+				"MarshalError",
+			), nil
+		}
+
+		return mcp.NewToolResultText(string(jsonData)), nil
+	}
+}
@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/dprince/openstack-k8s-mcp/internal/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	openstackv1beta1 "github.com/openstack-k8s-operators/openstack-operator/apis/core/v1beta1"
+)
+
+// ovnDataplaneImage is the image updated on the dataplane by the ovn service (runbook Step 5)
+const ovnDataplaneImage = "ovnControllerImage"
+
+// ImageChange is a container image that differs between the current and the target version
+type ImageChange struct {
+	Image   string `json:"image"`
+	Current string `json:"current,omitempty"`
+	Target  string `json:"target,omitempty"`
+	// Custom is set when the target image comes from spec.customContainerImages
+	Custom bool `json:"custom,omitempty"`
+}
+
+// ServiceImageChanges are the image changes of one service
+type ServiceImageChanges struct {
+	Service   string        `json:"service"`
+	Dataplane bool          `json:"dataplane,omitempty"`
+	Changes   []ImageChange `json:"changes"`
+}
+
+// planImageChanges compares the current and target images per service. It
+// returns the services with changes and the names of the untouched services.
+func planImageChanges(current, target, custom imageSet) ([]ServiceImageChanges, []string) {
+	changesByService := map[string]*ServiceImageChanges{}
+	services := map[string]bool{}
+	for _, key := range imageKeys(current, target) {
+		service := imageService(key)
+		services[service] = true
+		if current[key] == target[key] {
+			continue
+		}
+
+		changes, ok := changesByService[service]
+		if !ok {
+			changes = &ServiceImageChanges{Service: service, Changes: []ImageChange{}}
+			changesByService[service] = changes
+		}
+		_, isCustom := custom[key]
+		changes.Changes = append(changes.Changes, ImageChange{
+			Image:   key,
+			Current: current[key],
+			Target:  target[key],
+			Custom:  isCustom,
+		})
+		if isDataplaneImage(key) {
+			changes.Dataplane = true
+		}
+	}
+
+	changed := []ServiceImageChanges{}
+	unchanged := []string{}
+	for service := range services {
+		if changes, ok := changesByService[service]; ok {
+			changed = append(changed, *changes)
+		} else {
+			unchanged = append(unchanged, service)
+		}
+	}
+	sort.Slice(changed, func(i, j int) bool { return changed[i].Service < changed[j].Service })
+	sort.Strings(unchanged)
+	return changed, unchanged
+}
+
+// PlanMinorUpdateHandler handles the plan_minor_update tool call
+func PlanMinorUpdateHandler(k8sClient *client.K8sClient, settings Settings) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract parameters
+		namespace, ok := request.GetArguments()["namespace"].(string)
+		if !ok || namespace == "" {
+			namespace = settings.DefaultNamespace
+		}
+
+		name, ok := request.GetArguments()["name"].(string)
+
+		var osVersion *openstackv1beta1.OpenStackVersion
+		var err error
+
+		if ok && name != "" {
+			// Query the specific OpenStackVersion CR by name
+			osVersion, err = k8sClient.GetOpenStackVersion(ctx, namespace, name)
+			if err != nil {
+				return newStructuredError(
+					ErrorCodeK8sAPIError,
+					fmt.Sprintf("Failed to get OpenStackVersion '%s' in namespace '%s': %v", name, namespace, err),
+					"KubernetesAPIError",
+				), nil
+			}
+		} else {
+			// Auto-discover: List all OpenStackVersion CRs and use the first one
+			versions, err := k8sClient.ListOpenStackVersions(ctx, namespace)
+			if err != nil {
+				return newStructuredError(
+					ErrorCodeK8sAPIError,
+					fmt.Sprintf("Failed to list OpenStackVersions in namespace '%s': %v", namespace, err),
+					"KubernetesAPIError",
+				), nil
+			}
+
+			if len(versions) == 0 {
+				return newStructuredError(
+					ErrorCodeNotFound,
+					fmt.Sprintf("No OpenStackVersion CR found in namespace '%s'", namespace),
+					"ResourceNotFound",
+				), nil
+			}
+
+			osVersion = &versions[0]
+		}
+
+		// The target defaults to the version the operator offers
+		targetVersion, _ := request.GetArguments()["targetVersion"].(string)
+		if targetVersion == "" {
+			if osVersion.Status.AvailableVersion == nil {
+				return newStructuredError(
+					ErrorCodeInvalidParameter,
+					fmt.Sprintf("OpenStackVersion '%s' reports no availableVersion; pass targetVersion", osVersion.Name),
+					"ParameterValidationError",
+				), nil
+			}
+			targetVersion = *osVersion.Status.AvailableVersion
+		}
+
+		rolledOut, err := statusImages(osVersion)
+		if err != nil {
+			return newStructuredError(ErrorCodeMarshalError, err.Error(), "MarshalError"), nil
+		}
+		custom, err := customImages(osVersion)
+		if err != nil {
+			return newStructuredError(ErrorCodeMarshalError, err.Error(), "MarshalError"), nil
+		}
+		target, found, err := versionImages(osVersion, targetVersion, custom)
+		if err != nil {
+			return newStructuredError(ErrorCodeMarshalError, err.Error(), "MarshalError"), nil
+		}
+		if !found {
+			known := []string{}
+			for version := range osVersion.Status.ContainerImageVersionDefaults {
+				known = append(known, version)
+			}
+			sort.Strings(known)
+			return newStructuredError(
+				ErrorCodeNotFound,
+				fmt.Sprintf("OpenStackVersion '%s' has no container image defaults for version '%s'; known versions: %v", osVersion.Name, targetVersion, known),
+				"ResourceNotFound",
+			), nil
+		}
+
+		changed, unchanged := planImageChanges(rolledOut, target, custom)
+
+		changedImages := 0
+		dataplaneImages := []string{}
+		for _, service := range changed {
+			changedImages += len(service.Changes)
+			for _, change := range service.Changes {
+				if isDataplaneImage(change.Image) {
+					dataplaneImages = append(dataplaneImages, change.Image)
+				}
+			}
+		}
+		ovnDataplaneUpdate := rolledOut[ovnDataplaneImage] != target[ovnDataplaneImage]
+
+		warnings := []string{}
+		deployedVersion := osVersion.Status.DeployedVersion
+		if deployedVersion != nil && osVersion.Spec.TargetVersion != *deployedVersion {
+			warnings = append(warnings, fmt.Sprintf("An update to '%s' is in progress; the current images are already those of '%s', not of deployedVersion '%s'", osVersion.Spec.TargetVersion, osVersion.Spec.TargetVersion, *deployedVersion))
+		}
+		if deployedVersion != nil && targetVersion == *deployedVersion {
+			warnings = append(warnings, fmt.Sprintf("Version '%s' is already deployed", targetVersion))
+		}
+
+		// Build response
+		response := map[string]interface{}{
+			"name":                       osVersion.Name,
+			"namespace":                  osVersion.Namespace,
+			"currentTargetVersion":       osVersion.Spec.TargetVersion,
+			"deployedVersion":            deployedVersion,
+			"availableVersion":           osVersion.Status.AvailableVersion,
+			"targetVersion":              targetVersion,
+			"changedServices":            changed,
+			"unchangedServices":          unchanged,
+			"changedImages":              changedImages,
+			"totalImages":                len(target),
+			"dataplaneUpdateRequired":    len(dataplaneImages) > 0,
+			"ovnDataplaneUpdateRequired": ovnDataplaneUpdate,
+			"dataplaneImageChanges":      dataplaneImages,
+		}
+		if len(warnings) > 0 {
+			response["warnings"] = warnings
+		}
+
+		switch {
+		case changedImages == 0:
+			response["message"] = fmt.Sprintf("Updating to '%s' changes no container image.", targetVersion)
+		case len(dataplaneImages) > 0:
+			response["message"] = fmt.Sprintf("Updating to '%s' changes %d images in %d services, including %d dataplane images: the dataplane must be updated (runbook Steps 5-9).", targetVersion, changedImages, len(changed), len(dataplaneImages))
+		default:
+			response["message"] = fmt.Sprintf("Updating to '%s' changes %d images in %d services, all on the controlplane.", targetVersion, changedImages, len(changed))
+		}
+
+		// Convert response to JSON
+		jsonData, err := json.MarshalIndent(response, "", "  ")
+		if err != nil {
+			return newStructuredError(
+				ErrorCodeMarshalError,
+				fmt.Sprintf("Failed to marshal response: %v", err),
+				"MarshalError",
+			), nil
+		}
+
+		return mcp.NewToolResultText(string(jsonData)), nil
+	}
+}
@@ -0,0 +1,299 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/dprince/openstack-k8s-mcp/internal/client"
+	"github.com/dprince/openstack-k8s-mcp/internal/resume"
+	"github.com/mark3labs/mcp-go/server"
+	openstackv1beta1 "github.com/openstack-k8s-operators/openstack-operator/apis/core/v1beta1"
+)
+
+// UpdateRecordAnnotation is the OpenStackVersion annotation holding the
+// UpdateRecord of the last minor update driven through this server
+const UpdateRecordAnnotation = "openstack-k8s-mcp.openstack.org/minor-update"
+
+// Resume point sources returned by get_resume_step
+const (
+	ResumeSourceRecord     = "record"
+	ResumeSourceConditions = "conditions"
+)
+
+// DeploymentRecord is an OpenStackDataplaneDeployment created during a minor update
+type DeploymentRecord struct {
+	Service   string    `json:"service"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// UpdateRecord is the progress of a minor update, persisted on the
+// OpenStackVersion CR so a later session can tell exactly where it stopped
+type UpdateRecord struct {
+	TargetVersion string `json:"targetVersion"`
+	// FromVersion is the deployedVersion when the update started
+	FromVersion   string             `json:"fromVersion,omitempty"`
+	Step          int                `json:"step"`
+	StepStatus    string             `json:"stepStatus"`
+	Message       string             `json:"message,omitempty"`
+	Deployments   []DeploymentRecord `json:"deployments,omitempty"`
+	TriggeredBy   string             `json:"triggeredBy"`
+	StartedAt     time.Time          `json:"startedAt"`
+	StepStartedAt time.Time          `json:"stepStartedAt"`
+	UpdatedAt     time.Time          `json:"updatedAt"`
+}
+
+// inProgress reports whether r records an unfinished update to targetVersion
+func (r *UpdateRecord) inProgress(targetVersion string) bool {
+	return r != nil && r.TargetVersion == targetVersion &&
+		!(r.Step == StepUpdateComplete && r.StepStatus == StepStatusCompleted)
+}
+
+// Deployment returns the name of the deployment recorded for service, if any
+func (r *UpdateRecord) Deployment(service string) (string, bool) {
+	for _, deployment := range r.Deployments {
+		if deployment.Service == service {
+			return deployment.Name, true
+		}
+	}
+	return "", false
+}
+
+// setStep records the status of step
+func (r *UpdateRecord) setStep(step int, status, message string) {
+	now := time.Now().UTC()
+	if r.Step != step {
+		r.StepStartedAt = now
+	}
+	r.Step = step
+	r.StepStatus = status
+	r.Message = message
+	r.UpdatedAt = now
+}
+
+// addDeployment records the deployment created for service
+func (r *UpdateRecord) addDeployment(service, name string) {
+	for i, deployment := range r.Deployments {
+		if deployment.Service == service {
+			r.Deployments[i] = DeploymentRecord{Service: service, Name: name, CreatedAt: time.Now().UTC()}
+			return
+		}
+	}
+	r.Deployments = append(r.Deployments, DeploymentRecord{Service: service, Name: name, CreatedAt: time.Now().UTC()})
+}
+
+// readUpdateRecord returns the update record of an OpenStackVersion CR, or nil
+// when there is none
+func readUpdateRecord(osVersion *openstackv1beta1.OpenStackVersion) (*UpdateRecord, error) {
+	value, ok := osVersion.Annotations[UpdateRecordAnnotation]
+	if !ok || value == "" {
+		return nil, nil
+	}
+
+	var record UpdateRecord
+	if err := json.Unmarshal([]byte(value), &record); err != nil {
+		return nil, fmt.Errorf("invalid %s annotation: %w", UpdateRecordAnnotation, err)
+	}
+	return &record, nil
+}
+
+// saveUpdateRecord writes the update record to the OpenStackVersion CR
+func saveUpdateRecord(ctx context.Context, k8sClient *client.K8sClient, namespace, name string, record *UpdateRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal update record: %w", err)
+	}
+
+	if err := k8sClient.AnnotateOpenStackVersion(ctx, namespace, name, map[string]string{UpdateRecordAnnotation: string(data)}); err != nil {
+		return fmt.Errorf("failed to record update progress: %w", err)
+	}
+	return nil
+}
+
+// recordUpdateStep records on the OpenStackVersion CR that step of the update
+// to targetVersion has status, and the deployment created for service when
+// deployment is set. A record for another target version is replaced.
+func recordUpdateStep(ctx context.Context, k8sClient *client.K8sClient, osVersion *openstackv1beta1.OpenStackVersion, targetVersion string, step int, status, message, service, deployment, triggeredBy string) error {
+	record, err := readUpdateRecord(osVersion)
+	if err != nil || !record.inProgress(targetVersion) {
+		record = newUpdateRecord(osVersion, targetVersion, triggeredBy)
+	} else if record.Step > step {
+		// Never move the record of the update in progress backwards
+		return nil
+	}
+
+	record.setStep(step, status, message)
+	if deployment != "" {
+		record.addDeployment(service, deployment)
+	}
+	return saveUpdateRecord(ctx, k8sClient, osVersion.Namespace, osVersion.Name, record)
+}
+
+// recordToolStep records that a tool moved step of the update in progress to
+// status by hand. It discovers the OpenStackVersion CR when osVersion is nil,
+// and records nothing when no minor update is in progress. Errors are returned
+// as a warning for the tool result, since the tool itself succeeded.
+func recordToolStep(ctx context.Context, k8sClient *client.K8sClient, namespace string, osVersion *openstackv1beta1.OpenStackVersion, step int, status, message, service, deployment, tool string) string {
+	if osVersion == nil {
+		versions, err := k8sClient.ListOpenStackVersions(ctx, namespace)
+		if err != nil {
+			return fmt.Sprintf("Update progress was not recorded: failed to list OpenStackVersions: %v", err)
+		}
+		if len(versions) == 0 {
+			return ""
+		}
+		osVersion = &versions[0]
+	}
+
+	if !updateInProgress(osVersion) {
+		return ""
+	}
+
+	if err := recordUpdateStep(ctx, k8sClient, osVersion, osVersion.Spec.TargetVersion, step, status, message, service, deployment, triggeredBy(ctx, tool)); err != nil {
+		return fmt.Sprintf("Update progress was not recorded: %v", err)
+	}
+	return ""
+}
+
+// updateInProgress reports whether a minor update of osVersion is in
+// progress: its targetVersion is not deployed yet, or the update record
+// describes an unfinished update to it
+func updateInProgress(osVersion *openstackv1beta1.OpenStackVersion) bool {
+	deployed := osVersion.Status.DeployedVersion
+	if deployed != nil && *deployed != osVersion.Spec.TargetVersion {
+		return true
+	}
+	record, err := readUpdateRecord(osVersion)
+	return err == nil && record.inProgress(osVersion.Spec.TargetVersion)
+}
+
+// newUpdateRecord starts the record of an update of osVersion to targetVersion
+func newUpdateRecord(osVersion *openstackv1beta1.OpenStackVersion, targetVersion, triggeredBy string) *UpdateRecord {
+	now := time.Now().UTC()
+	fromVersion := ""
+	if osVersion.Status.DeployedVersion != nil {
+		fromVersion = *osVersion.Status.DeployedVersion
+	}
+	return &UpdateRecord{
+		TargetVersion: targetVersion,
+		FromVersion:   fromVersion,
+		TriggeredBy:   triggeredBy,
+		StartedAt:     now,
+		StepStartedAt: now,
+		UpdatedAt:     now,
+	}
+}
+
+// triggeredBy describes the MCP client calling tool, for the update record
+func triggeredBy(ctx context.Context, tool string) string {
+	clientName := "unknown client"
+	if session := server.ClientSessionFromContext(ctx); session != nil {
+		if withInfo, ok := session.(server.SessionWithClientInfo); ok {
+			if info := withInfo.GetClientInfo(); info.Name != "" {
+				clientName = info.Name
+				if info.Version != "" {
+					clientName = fmt.Sprintf("%s/%s", info.Name, info.Version)
+				}
+			}
+		}
+	}
+	return fmt.Sprintf("%s via %s", clientName, tool)
+}
+
+// resumeFromRecord returns the step to resume from according to the update
+// record, and why. It returns false when the record does not describe the
+// update in progress, so the caller should infer the step from conditions.
+// deploymentReady reports whether a recorded dataplane deployment is Ready.
+func resumeFromRecord(osVersion *openstackv1beta1.OpenStackVersion, record *UpdateRecord, deploymentReady func(name string) bool) (int, string, bool) {
+	// A finished update says nothing about the next one, and before Step 3
+	// the update has not started
+	if !record.inProgress(osVersion.Spec.TargetVersion) || record.Step < StepSetTargetVersion {
+		return 0, "", false
+	}
+
+	step := record.Step
+	completion := ""
+	switch {
+	case step == StepDeployOVNDataplane || step == StepDeployDataplane:
+		// The deployment exists once it is recorded; monitor it instead of creating another
+		service := updateServiceOVN
+		if step == StepDeployDataplane {
+			service = updateServiceUpdate
+		}
+		if name, ok := record.Deployment(service); ok {
+			step = nextStep(step)
+			// The record is not updated when the deployment finishes, so its
+			// Ready condition tells whether the deploy step completed
+			if record.StepStatus != StepStatusCompleted && deploymentReady(name) {
+				completion = fmt.Sprintf(" OpenStackDataplaneDeployment '%s' is Ready, so %s completed.", name, stepTitle(record.Step))
+			}
+		} else if record.StepStatus == StepStatusCompleted {
+			step = nextStep(step)
+		}
+	case record.StepStatus == StepStatusCompleted:
+		step = nextStep(step)
+	}
+
+	return step, fmt.Sprintf("Update record (started %s by %s) shows %s %s at %s.%s Resume at %s.",
+		record.StartedAt.Format(time.RFC3339), record.TriggeredBy, stepTitle(record.Step), record.StepStatus,
+		record.UpdatedAt.Format(time.RFC3339), completion, stepTitle(step)), true
+}
+
+// recordedDeploymentReady returns a function reporting whether a dataplane
+// deployment in namespace is Ready. A deployment that cannot be read is not.
+func recordedDeploymentReady(ctx context.Context, k8sClient *client.K8sClient, namespace string) func(name string) bool {
+	return func(name string) bool {
+		deployment, err := k8sClient.GetDataplaneDeployment(ctx, namespace, name)
+		if err != nil {
+			return false
+		}
+		status, _ := readyCondition(deployment)
+		return status == client.ConditionTrue
+	}
+}
+
+// resumePointResult is where to resume a minor update, and why
+type resumePointResult struct {
+	Step        int
+	Explanation string
+	// Source is ResumeSourceRecord or ResumeSourceConditions
+	Source string
+	// Decision is what the conditions alone say, even when the record wins
+	Decision resume.Decision
+	Record   *UpdateRecord
+}
+
+// resumePoint determines the step to resume a minor update from, preferring
+// the update record and falling back to the decision table. Conditions still
+// win when they show the update got further than the record. The recorded
+// dataplane deployments are read to tell whether a deploy step completed.
+func resumePoint(ctx context.Context, k8sClient *client.K8sClient, osVersion *openstackv1beta1.OpenStackVersion) resumePointResult {
+	decision := resumeDecision(osVersion)
+	point := resumePointResult{
+		Step:        decision.Step,
+		Explanation: decision.Explanation,
+		Source:      ResumeSourceConditions,
+		Decision:    decision,
+	}
+
+	record, err := readUpdateRecord(osVersion)
+	if err != nil {
+		point.Explanation = fmt.Sprintf("%s Ignoring the update record: %v.", decision.Explanation, err)
+		return point
+	}
+	point.Record = record
+
+	if step, recordExplanation, ok := resumeFromRecord(osVersion, record, recordedDeploymentReady(ctx, k8sClient, osVersion.Namespace)); ok {
+		if decision.Step > step {
+			point.Explanation = fmt.Sprintf("%s The conditions show more progress: %s", recordExplanation, decision.Explanation)
+			return point
+		}
+		point.Step = step
+		point.Explanation = recordExplanation
+		point.Source = ResumeSourceRecord
+	}
+
+	return point
+}
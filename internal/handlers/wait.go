@@ -0,0 +1,389 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/dprince/openstack-k8s-mcp/internal/client"
+	"github.com/dprince/openstack-k8s-mcp/internal/operations"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// DefaultWaitCondition is waited for when no condition argument is given
+const DefaultWaitCondition = "Ready"
+
+// notificationLogFunc returns a logging function that sends notifications to the client
+func notificationLogFunc(ctx context.Context) func(string) {
+	// Get the MCP server from context to send log notifications
+	mcpServer := server.ServerFromContext(ctx)
+
+	return func(message string) {
+		if mcpServer != nil {
+			// Send a logging notification that will appear in the MCP client console
+			_ = mcpServer.SendNotificationToClient(ctx, "notifications/message", map[string]interface{}{
+				"level":   "info",
+				"message": message,
+			})
+		}
+	}
+}
+
+// progressReporter returns a function that reports progress to the client.
+// When the request carries a progress token it sends notifications/progress
+// with the given progress, total and message; otherwise it falls back to
+// notifications/message log lines. Operations started with async record the
+// message for get_operation instead.
+func progressReporter(ctx context.Context, request mcp.CallToolRequest) func(progress, total float64, message string) {
+	if op := operations.FromContext(ctx); op != nil {
+		return func(progress, total float64, message string) {
+			op.SetProgress(message)
+		}
+	}
+
+	var progressToken mcp.ProgressToken
+	if request.Params.Meta != nil {
+		progressToken = request.Params.Meta.ProgressToken
+	}
+
+	if progressToken == nil {
+		logFunc := notificationLogFunc(ctx)
+		return func(progress, total float64, message string) {
+			logFunc(message)
+		}
+	}
+
+	mcpServer := server.ServerFromContext(ctx)
+
+	return func(progress, total float64, message string) {
+		if mcpServer == nil {
+			return
+		}
+
+		_ = mcpServer.SendNotificationToClient(ctx, string(mcp.MethodNotificationProgress), map[string]interface{}{
+			"progressToken": progressToken,
+			"progress":      progress,
+			"total":         total,
+			"message":       message,
+		})
+	}
+}
+
+// waitProgressFunc returns a function that reports wait progress to the client,
+// with the elapsed and total time in seconds and the current condition status
+// and reason. See progressReporter.
+func waitProgressFunc(ctx context.Context, request mcp.CallToolRequest) func(client.WaitProgress) {
+	report := progressReporter(ctx, request)
+	return func(progress client.WaitProgress) {
+		report(progress.Elapsed.Seconds(), progress.Timeout.Seconds(), progressMessage(progress))
+	}
+}
+
+// progressMessage formats a progress update with the current condition status and reason
+func progressMessage(progress client.WaitProgress) string {
+	if progress.Condition == nil {
+		return progress.Message
+	}
+	return fmt.Sprintf("%s [%s]", progress.Message, progress.Condition)
+}
+
+// DeploymentFailReasons are the condition reasons that stop a wait on an
+// OpenStackDataplaneDeployment by default, since a failed deployment does not recover
+var DeploymentFailReasons = []string{"Error"}
+
+// parseConditionExpectation parses "Type" or "Type=Status", where Status is
+// True, False, Unknown or Absent
+func parseConditionExpectation(value string) (client.ConditionExpectation, error) {
+	conditionType, status, found := strings.Cut(value, "=")
+	conditionType = strings.TrimSpace(conditionType)
+	if conditionType == "" {
+		return client.ConditionExpectation{}, fmt.Errorf("condition '%s' has an empty type", value)
+	}
+
+	if !found {
+		return client.ConditionExpectation{Type: conditionType, Status: client.ConditionTrue}, nil
+	}
+
+	for _, valid := range []string{client.ConditionTrue, client.ConditionFalse, client.ConditionUnknown, client.ConditionAbsent} {
+		if strings.EqualFold(strings.TrimSpace(status), valid) {
+			return client.ConditionExpectation{Type: conditionType, Status: valid}, nil
+		}
+	}
+
+	return client.ConditionExpectation{}, fmt.Errorf("condition '%s' has invalid status '%s' (must be True, False, Unknown or Absent)", value, status)
+}
+
+// stringArrayArgument returns an optional array of strings argument and whether it was given
+func stringArrayArgument(request mcp.CallToolRequest, name string) ([]string, bool, error) {
+	raw, ok := request.GetArguments()[name]
+	if !ok {
+		return nil, false, nil
+	}
+
+	array, ok := raw.([]interface{})
+	if !ok {
+		return nil, true, fmt.Errorf("%s must be an array of strings", name)
+	}
+
+	values := make([]string, len(array))
+	for i, item := range array {
+		value, ok := item.(string)
+		if !ok {
+			return nil, true, fmt.Errorf("%s element at index %d must be a string", name, i)
+		}
+		values[i] = value
+	}
+
+	return values, true, nil
+}
+
+// waitSpecArguments builds the WaitSpec from the condition, conditions, mode and
+// failReasons arguments. defaultCondition is waited for when neither condition
+// argument is given; when it is empty a condition is required.
+func waitSpecArguments(request mcp.CallToolRequest, defaultCondition string, defaultFailReasons []string) (client.WaitSpec, error) {
+	spec := client.WaitSpec{
+		Mode:        client.WaitAll,
+		FailReasons: defaultFailReasons,
+	}
+
+	values, _, err := stringArrayArgument(request, "conditions")
+	if err != nil {
+		return spec, err
+	}
+	if condition, ok := request.GetArguments()["condition"].(string); ok && condition != "" {
+		values = append([]string{condition}, values...)
+	}
+	if len(values) == 0 {
+		if defaultCondition == "" {
+			return spec, fmt.Errorf("condition parameter is required")
+		}
+		values = []string{defaultCondition}
+	}
+
+	for _, value := range values {
+		expected, err := parseConditionExpectation(value)
+		if err != nil {
+			return spec, err
+		}
+		spec.Conditions = append(spec.Conditions, expected)
+	}
+
+	if mode, ok := request.GetArguments()["mode"].(string); ok && mode != "" {
+		switch client.WaitMode(mode) {
+		case client.WaitAll, client.WaitAny:
+			spec.Mode = client.WaitMode(mode)
+		default:
+			return spec, fmt.Errorf("mode '%s' is invalid (must be '%s' or '%s')", mode, client.WaitAll, client.WaitAny)
+		}
+	}
+
+	// An explicit failReasons argument replaces the defaults, so [] disables fail-fast
+	failReasons, ok, err := stringArrayArgument(request, "failReasons")
+	if err != nil {
+		return spec, err
+	}
+	if ok {
+		spec.FailReasons = failReasons
+	}
+
+	return spec, nil
+}
+
+// waitSpecError returns the tool result for invalid wait arguments
+func waitSpecError(err error) *mcp.CallToolResult {
+	return newStructuredError(
+		ErrorCodeInvalidParameter,
+		err.Error(),
+		"ParameterValidationError",
+	)
+}
+
+// conditionStateResponse converts a condition state to its JSON response
+func conditionStateResponse(state client.ConditionState) map[string]interface{} {
+	response := map[string]interface{}{
+		"type":  state.Type,
+		"found": state.Found,
+	}
+	if state.Found {
+		response["status"] = state.Status
+		response["reason"] = state.Reason
+		response["message"] = state.Message
+	}
+	return response
+}
+
+// waitForCondition waits for spec on the CRs of kind using the timeout and
+// pollInterval arguments of request, and builds the tool result. An empty name
+// waits on every CR of kind in the namespace.
+func waitForCondition(ctx context.Context, request mcp.CallToolRequest, k8sClient *client.K8sClient, settings Settings, kind client.Kind, namespace, name string, spec client.WaitSpec) *mcp.CallToolResult {
+	// Optional timeout parameter (default from settings, 600 seconds)
+	timeout := settings.WaitTimeout
+	if timeoutVal, ok := request.GetArguments()["timeout"].(float64); ok {
+		timeout = int(timeoutVal)
+	}
+
+	// Optional pollInterval parameter (default from settings, 5 seconds)
+	pollInterval := settings.WaitPollInterval
+	if pollIntervalVal, ok := request.GetArguments()["pollInterval"].(float64); ok {
+		pollInterval = int(pollIntervalVal)
+	}
+
+	conditions := make([]string, len(spec.Conditions))
+	for i, expected := range spec.Conditions {
+		conditions[i] = expected.String()
+	}
+
+	// Wait for the conditions
+	status, err := k8sClient.WaitForResourceConditions(ctx, kind, namespace, name, spec, timeout, pollInterval, waitProgressFunc(ctx, request))
+	if err != nil {
+		target := name
+		if target == "" {
+			target = fmt.Sprintf("all %s CRs", kind)
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to wait for conditions %s on '%s' in namespace '%s': %v", strings.Join(conditions, ", "), target, namespace, err))
+	}
+
+	// Build response
+	response := map[string]interface{}{
+		"namespace":  namespace,
+		"conditions": conditions,
+		"mode":       spec.Mode,
+		"met":        status.Met,
+		"failed":     status.Failed,
+		"message":    status.Message,
+		"reason":     status.Reason,
+	}
+
+	if len(spec.Conditions) == 1 {
+		response["condition"] = spec.Conditions[0].Type
+	}
+
+	if status.Trigger != nil {
+		trigger := conditionStateResponse(*status.Trigger)
+		trigger["object"] = status.TriggerObject
+		response["trigger"] = trigger
+	}
+
+	if name != "" {
+		response["name"] = name
+	} else {
+		objects := make([]map[string]interface{}, len(status.Objects))
+		for i, obj := range status.Objects {
+			states := make([]map[string]interface{}, len(obj.Conditions))
+			for j, state := range obj.Conditions {
+				states[j] = conditionStateResponse(state)
+			}
+			objects[i] = map[string]interface{}{
+				"name":       obj.Name,
+				"met":        obj.Met,
+				"failed":     obj.Failed,
+				"conditions": states,
+			}
+		}
+		response["objects"] = objects
+	}
+
+	// Convert response to JSON
+	jsonData, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal response: %v", err))
+	}
+
+	return mcp.NewToolResultText(string(jsonData))
+}
+
+// WaitOpenStackControlPlaneHandler handles the wait_openstack_controlplane tool call
+func WaitOpenStackControlPlaneHandler(k8sClient *client.K8sClient, settings Settings) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract parameters
+		namespace, ok := request.GetArguments()["namespace"].(string)
+		if !ok || namespace == "" {
+			namespace = settings.DefaultNamespace
+		}
+
+		name, ok := request.GetArguments()["name"].(string)
+
+		// If name is not provided, auto-discover the first OpenStackControlPlane in the namespace
+		if !ok || name == "" {
+			controlPlanes, err := k8sClient.ListOpenStackControlPlanes(ctx, namespace)
+			if err != nil {
+				return newStructuredError(
+					ErrorCodeK8sAPIError,
+					fmt.Sprintf("Failed to list OpenStackControlPlanes in namespace '%s': %v", namespace, err),
+					"KubernetesAPIError",
+				), nil
+			}
+
+			if len(controlPlanes) == 0 {
+				return newStructuredError(
+					ErrorCodeNotFound,
+					fmt.Sprintf("No OpenStackControlPlane CR found in namespace '%s'", namespace),
+					"ResourceNotFound",
+				), nil
+			}
+
+			metadata, _ := controlPlanes[0]["metadata"].(map[string]interface{})
+			name, _ = metadata["name"].(string)
+		}
+
+		spec, err := waitSpecArguments(request, DefaultWaitCondition, nil)
+		if err != nil {
+			return waitSpecError(err), nil
+		}
+
+		return waitForCondition(ctx, request, k8sClient, settings, client.KindOpenStackControlPlane, namespace, name, spec), nil
+	}
+}
+
+// WaitDataplaneNodeSetsHandler handles the wait_dataplane_nodesets tool call.
+// Without a name it waits for the condition on every NodeSet in the namespace.
+func WaitDataplaneNodeSetsHandler(k8sClient *client.K8sClient, settings Settings) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract parameters
+		namespace, ok := request.GetArguments()["namespace"].(string)
+		if !ok || namespace == "" {
+			namespace = settings.DefaultNamespace
+		}
+
+		name, _ := request.GetArguments()["name"].(string)
+
+		spec, err := waitSpecArguments(request, DefaultWaitCondition, nil)
+		if err != nil {
+			return waitSpecError(err), nil
+		}
+
+		return waitForCondition(ctx, request, k8sClient, settings, client.KindOpenStackDataplaneNodeSet, namespace, name, spec), nil
+	}
+}
+
+// WaitDataplaneDeploymentHandler handles the wait_dataplane_deployment tool call
+func WaitDataplaneDeploymentHandler(k8sClient *client.K8sClient, settings Settings) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Extract parameters
+		namespace, ok := request.GetArguments()["namespace"].(string)
+		if !ok || namespace == "" {
+			namespace = settings.DefaultNamespace
+		}
+
+		name, ok := request.GetArguments()["name"].(string)
+		if !ok || name == "" {
+			return newStructuredError(
+				ErrorCodeInvalidParameter,
+				"name parameter is required and must be a non-empty string",
+				"ParameterValidationError",
+			), nil
+		}
+
+		// Replace dots with dashes in the name
+		name = strings.ReplaceAll(name, ".", "-")
+
+		spec, err := waitSpecArguments(request, DefaultWaitCondition, DeploymentFailReasons)
+		if err != nil {
+			return waitSpecError(err), nil
+		}
+
+		return waitForCondition(ctx, request, k8sClient, settings, client.KindOpenStackDataplaneDeployment, namespace, name, spec), nil
+	}
+}
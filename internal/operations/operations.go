@@ -0,0 +1,253 @@
+package operations
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Status is the state of an operation
+type Status string
+
+const (
+	StatusRunning   Status = "running"
+	StatusCompleted Status = "completed"
+	StatusFailed    Status = "failed"
+	StatusCancelled Status = "cancelled"
+)
+
+// ErrNotFound is returned for unknown or expired operation IDs
+var ErrNotFound = errors.New("operation not found")
+
+// RunFunc runs an operation. It returns the result text and whether the
+// operation failed, and should stop early when ctx is cancelled.
+type RunFunc func(ctx context.Context) (result string, failed bool)
+
+// Operation is a long-running action started in the background
+type Operation struct {
+	id string
+	// session is the ID of the client session that started the operation;
+	// other sessions cannot see or cancel it
+	session   string
+	tool      string
+	arguments map[string]interface{}
+	startedAt time.Time
+	cancel    context.CancelFunc
+
+	mu              sync.Mutex
+	status          Status
+	progress        string
+	result          string
+	completedAt     time.Time
+	cancelRequested bool
+}
+
+// Snapshot is a point-in-time copy of an operation's state
+type Snapshot struct {
+	ID          string
+	Tool        string
+	Arguments   map[string]interface{}
+	Status      Status
+	StartedAt   time.Time
+	CompletedAt time.Time
+	Progress    string
+	Result      string
+}
+
+// ID returns the operation ID
+func (o *Operation) ID() string {
+	return o.id
+}
+
+// SetProgress records the latest progress message of the operation
+func (o *Operation) SetProgress(message string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.progress = message
+}
+
+// Snapshot returns a copy of the operation's current state
+func (o *Operation) Snapshot() Snapshot {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return Snapshot{
+		ID:          o.id,
+		Tool:        o.tool,
+		Arguments:   o.arguments,
+		Status:      o.status,
+		StartedAt:   o.startedAt,
+		CompletedAt: o.completedAt,
+		Progress:    o.progress,
+		Result:      o.result,
+	}
+}
+
+// finish records the result of the run
+func (o *Operation) finish(result string, failed bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.result = result
+	o.completedAt = time.Now()
+	switch {
+	case o.cancelRequested:
+		o.status = StatusCancelled
+	case failed:
+		o.status = StatusFailed
+	default:
+		o.status = StatusCompleted
+	}
+}
+
+// expired reports whether the operation completed more than retention ago
+func (o *Operation) expired(now time.Time, retention time.Duration) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.status != StatusRunning && now.Sub(o.completedAt) > retention
+}
+
+type contextKey struct{}
+
+// WithOperation returns a context carrying op, so the code it runs can report progress
+func WithOperation(ctx context.Context, op *Operation) context.Context {
+	return context.WithValue(ctx, contextKey{}, op)
+}
+
+// FromContext returns the operation running with ctx, or nil
+func FromContext(ctx context.Context) *Operation {
+	op, _ := ctx.Value(contextKey{}).(*Operation)
+	return op
+}
+
+// Manager starts operations and keeps their results for the retention period
+type Manager struct {
+	retention time.Duration
+
+	mu         sync.Mutex
+	operations map[string]*Operation
+}
+
+// NewManager creates a Manager that keeps completed operations for retention
+func NewManager(retention time.Duration) *Manager {
+	return &Manager{
+		retention:  retention,
+		operations: map[string]*Operation{},
+	}
+}
+
+// Retention returns how long completed operations are kept
+func (m *Manager) Retention() time.Duration {
+	return m.retention
+}
+
+// Start runs run in the background on behalf of session and returns its
+// operation. The operation outlives ctx; only its values are inherited. It
+// stops when cancelled with Cancel.
+func (m *Manager) Start(ctx context.Context, session, tool string, arguments map[string]interface{}, run RunFunc) (*Operation, error) {
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+
+	runCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	op := &Operation{
+		id:        id,
+		session:   session,
+		tool:      tool,
+		arguments: arguments,
+		startedAt: time.Now(),
+		cancel:    cancel,
+		status:    StatusRunning,
+	}
+
+	m.mu.Lock()
+	m.pruneLocked()
+	m.operations[id] = op
+	m.mu.Unlock()
+
+	go func() {
+		defer cancel()
+		result, failed := run(WithOperation(runCtx, op))
+		op.finish(result, failed)
+	}()
+
+	return op, nil
+}
+
+// Get returns the operation with the given ID started by session. Operations
+// of other sessions are reported as not found.
+func (m *Manager) Get(id, session string) (*Operation, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.pruneLocked()
+	op, ok := m.operations[id]
+	if !ok || op.session != session {
+		return nil, fmt.Errorf("%w: '%s'", ErrNotFound, id)
+	}
+	return op, nil
+}
+
+// List returns every operation started by session, oldest first
+func (m *Manager) List(session string) []*Operation {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.pruneLocked()
+	ops := make([]*Operation, 0, len(m.operations))
+	for _, op := range m.operations {
+		if op.session != session {
+			continue
+		}
+		ops = append(ops, op)
+	}
+	sort.Slice(ops, func(i, j int) bool {
+		return ops[i].startedAt.Before(ops[j].startedAt)
+	})
+	return ops
+}
+
+// Cancel stops a running operation started by session. Cancelling a finished
+// operation is an error.
+func (m *Manager) Cancel(id, session string) (*Operation, error) {
+	op, err := m.Get(id, session)
+	if err != nil {
+		return nil, err
+	}
+
+	op.mu.Lock()
+	if op.status != StatusRunning {
+		status := op.status
+		op.mu.Unlock()
+		return op, fmt.Errorf("operation '%s' is already %s", id, status)
+	}
+	op.cancelRequested = true
+	op.mu.Unlock()
+
+	op.cancel()
+	return op, nil
+}
+
+// pruneLocked drops operations that completed before the retention period; m.mu must be held
+func (m *Manager) pruneLocked() {
+	now := time.Now()
+	for id, op := range m.operations {
+		if op.expired(now, m.retention) {
+			delete(m.operations, id)
+		}
+	}
+}
+
+// newID returns a random operation ID
+func newID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate operation ID: %w", err)
+	}
+	return "op-" + hex.EncodeToString(b), nil
+}
@@ -0,0 +1,330 @@
+// Package resume decides which minor update runbook step to resume from,
+// based on the versions and conditions of an OpenStackVersion CR. The
+// decision logic is the declarative rule table in table.go.
+package resume
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	openstackv1beta1 "github.com/openstack-k8s-operators/openstack-operator/apis/core/v1beta1"
+)
+
+// Runbook step numbers
+const (
+	StepDetermineResumePoint   = 1
+	StepPreUpgradeValidation   = 2
+	StepSetTargetVersion       = 3
+	StepMonitorOVNControlplane = 4
+	StepDeployOVNDataplane     = 5
+	StepMonitorOVNDataplane    = 6
+	StepMonitorControlplane    = 7
+	StepDeployDataplane        = 8
+	StepMonitorDataplane       = 9
+	StepUpdateComplete         = 10
+)
+
+// stepNames holds the name of every runbook step
+var stepNames = map[int]string{
+	StepDetermineResumePoint:   "DetermineResumePoint",
+	StepPreUpgradeValidation:   "PreUpgradeValidation",
+	StepSetTargetVersion:       "SetTargetVersion",
+	StepMonitorOVNControlplane: "MonitorOVNControlplane",
+	StepDeployOVNDataplane:     "DeployOVNDataplane",
+	StepMonitorOVNDataplane:    "MonitorOVNDataplane",
+	StepMonitorControlplane:    "MonitorControlplane",
+	StepDeployDataplane:        "DeployDataplane",
+	StepMonitorDataplane:       "MonitorDataplane",
+	StepUpdateComplete:         "UpdateComplete",
+}
+
+// StepName returns the name of a runbook step, e.g. "MonitorOVNControlplane"
+func StepName(step int) string {
+	if name, ok := stepNames[step]; ok {
+		return name
+	}
+	return fmt.Sprintf("Step%d", step)
+}
+
+// Condition status values
+const (
+	ConditionTrue    = "True"
+	ConditionFalse   = "False"
+	ConditionUnknown = "Unknown"
+)
+
+// State is what the decision is based on: the versions and the conditions of
+// an OpenStackVersion CR
+type State struct {
+	TargetVersion    string
+	AvailableVersion *string
+	DeployedVersion  *string
+	// Conditions maps condition types to their status. Conditions the CR
+	// does not report are absent.
+	Conditions map[string]string
+}
+
+// StateFromOpenStackVersion returns the State of an OpenStackVersion CR
+func StateFromOpenStackVersion(osVersion *openstackv1beta1.OpenStackVersion) State {
+	conditions := make(map[string]string, len(osVersion.Status.Conditions))
+	for _, cond := range osVersion.Status.Conditions {
+		conditions[string(cond.Type)] = string(cond.Status)
+	}
+	return State{
+		TargetVersion:    osVersion.Spec.TargetVersion,
+		AvailableVersion: osVersion.Status.AvailableVersion,
+		DeployedVersion:  osVersion.Status.DeployedVersion,
+		Conditions:       conditions,
+	}
+}
+
+// notReady reports whether the state has conditionType with a status other than True
+func (s State) notReady(conditionType string) bool {
+	status, ok := s.Conditions[conditionType]
+	return ok && status != ConditionTrue
+}
+
+// NotReadyConditions returns the types of the reported conditions that are
+// not True, in catalogue order followed by conditions missing from it
+func (s State) NotReadyConditions() []string {
+	notReady := []string{}
+	for _, cond := range Conditions {
+		if s.notReady(cond.Type) {
+			notReady = append(notReady, cond.Type)
+		}
+	}
+	other := []string{}
+	for conditionType := range s.Conditions {
+		if _, known := conditionRoles[conditionType]; !known && s.notReady(conditionType) {
+			other = append(other, conditionType)
+		}
+	}
+	sort.Strings(other)
+	return append(notReady, other...)
+}
+
+// allReady reports whether every reported condition that is not
+// informational is True
+func (s State) allReady() bool {
+	for conditionType := range s.Conditions {
+		if role, ok := conditionRoles[conditionType]; ok && role == RoleInformational {
+			continue
+		}
+		if s.notReady(conditionType) {
+			return false
+		}
+	}
+	return true
+}
+
+// versionString returns a version for explanations, "nil" when it is not set
+func versionString(version *string) string {
+	if version == nil {
+		return "nil"
+	}
+	return *version
+}
+
+// VersionCheck compares the versions of the state
+type VersionCheck string
+
+const (
+	AvailableKnown     VersionCheck = "availableVersion is set"
+	AvailableUnknown   VersionCheck = "availableVersion is not set"
+	TargetIsAvailable  VersionCheck = "targetVersion == availableVersion"
+	TargetNotAvailable VersionCheck = "targetVersion != availableVersion"
+	TargetIsDeployed   VersionCheck = "targetVersion == deployedVersion"
+	TargetNotDeployed  VersionCheck = "targetVersion != deployedVersion"
+	AvailableNewer     VersionCheck = "availableVersion != deployedVersion"
+)
+
+// holds reports whether the version check holds for s
+func (c VersionCheck) holds(s State) bool {
+	equal := func(a string, b *string) bool {
+		return b != nil && a == *b
+	}
+	switch c {
+	case AvailableKnown:
+		return s.AvailableVersion != nil
+	case AvailableUnknown:
+		return s.AvailableVersion == nil
+	case TargetIsAvailable:
+		return equal(s.TargetVersion, s.AvailableVersion)
+	case TargetNotAvailable:
+		return !equal(s.TargetVersion, s.AvailableVersion)
+	case TargetIsDeployed:
+		return equal(s.TargetVersion, s.DeployedVersion)
+	case TargetNotDeployed:
+		return !equal(s.TargetVersion, s.DeployedVersion)
+	case AvailableNewer:
+		return s.AvailableVersion != nil && !equal(*s.AvailableVersion, s.DeployedVersion)
+	}
+	return false
+}
+
+// Match is the part of a rule that selects states. Every non-empty field
+// must hold; an empty Match holds for every state.
+type Match struct {
+	// Versions must all hold
+	Versions []VersionCheck
+	// NotReadyAny holds when at least one of these conditions is reported and not True
+	NotReadyAny []string
+	// AllTrue holds when all of these conditions are reported and True
+	AllTrue []string
+	// AllAbsent holds when none of these conditions is reported
+	AllAbsent []string
+	// AllReady holds when every reported, non-informational condition is True
+	AllReady bool
+}
+
+// holds reports whether m selects s
+func (m Match) holds(s State) bool {
+	for _, check := range m.Versions {
+		if !check.holds(s) {
+			return false
+		}
+	}
+	if len(m.NotReadyAny) > 0 {
+		found := false
+		for _, conditionType := range m.NotReadyAny {
+			if s.notReady(conditionType) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	for _, conditionType := range m.AllTrue {
+		if s.Conditions[conditionType] != ConditionTrue {
+			return false
+		}
+	}
+	for _, conditionType := range m.AllAbsent {
+		if _, ok := s.Conditions[conditionType]; ok {
+			return false
+		}
+	}
+	if m.AllReady && !s.allReady() {
+		return false
+	}
+	return true
+}
+
+// Rule is one entry of the decision table. A rule with Children refines the
+// decision with the first child that matches; a rule without Children
+// decides Step.
+type Rule struct {
+	// ID identifies the rule in the matched path. IDs are stable within a table version.
+	ID string
+	// Description explains the rule. {targetVersion}, {availableVersion},
+	// {deployedVersion} and {notReadyConditions} are replaced with the state.
+	Description string
+	When        Match
+	Step        int
+	Children    []Rule
+}
+
+// Table is a versioned decision table
+type Table struct {
+	Version string
+	Rules   []Rule
+}
+
+// MatchedRule is a rule on the matched path of a decision
+type MatchedRule struct {
+	ID          string `json:"id"`
+	Description string `json:"description"`
+}
+
+// Decision is the step to resume from and the rules that chose it
+type Decision struct {
+	TableVersion       string        `json:"tableVersion"`
+	Step               int           `json:"step"`
+	StepName           string        `json:"stepName"`
+	Path               []MatchedRule `json:"path"`
+	NotReadyConditions []string      `json:"notReadyConditions"`
+	Explanation        string        `json:"explanation"`
+}
+
+// RulePath returns the IDs of the matched rules, from the top of the table down
+func (d Decision) RulePath() []string {
+	ids := make([]string, len(d.Path))
+	for i, rule := range d.Path {
+		ids[i] = rule.ID
+	}
+	return ids
+}
+
+// Decide returns the resume decision of the default table for s
+func Decide(s State) (Decision, error) {
+	return DefaultTable.Decide(s)
+}
+
+// Decide walks the table for s: at each level the first matching rule is
+// taken, until a rule without children decides the step
+func (t Table) Decide(s State) (Decision, error) {
+	notReady := s.NotReadyConditions()
+
+	decision := Decision{
+		TableVersion:       t.Version,
+		Path:               []MatchedRule{},
+		NotReadyConditions: notReady,
+	}
+
+	rules := t.Rules
+	for {
+		var matched *Rule
+		for i := range rules {
+			if rules[i].When.holds(s) {
+				matched = &rules[i]
+				break
+			}
+		}
+		if matched == nil {
+			return decision, fmt.Errorf("resume table %s: no rule matches after %v", t.Version, decision.RulePath())
+		}
+
+		decision.Path = append(decision.Path, MatchedRule{
+			ID:          matched.ID,
+			Description: describe(matched.Description, s, notReady),
+		})
+
+		if len(matched.Children) == 0 {
+			decision.Step = matched.Step
+			break
+		}
+		rules = matched.Children
+	}
+
+	decision.StepName = StepName(decision.Step)
+
+	descriptions := make([]string, len(decision.Path))
+	for i, rule := range decision.Path {
+		descriptions[i] = rule.Description
+	}
+	decision.Explanation = fmt.Sprintf("%s Resume at step %d (%s).", strings.Join(descriptions, " "), decision.Step, decision.StepName)
+
+	return decision, nil
+}
+
+// describe fills the placeholders of a rule description with the state
+func describe(description string, s State, notReady []string) string {
+	if !strings.Contains(description, "{") {
+		return description
+	}
+	placeholders := map[string]func() string{
+		"{targetVersion}":      func() string { return s.TargetVersion },
+		"{availableVersion}":   func() string { return versionString(s.AvailableVersion) },
+		"{deployedVersion}":    func() string { return versionString(s.DeployedVersion) },
+		"{notReadyConditions}": func() string { return fmt.Sprintf("%v", notReady) },
+	}
+	for placeholder, value := range placeholders {
+		if strings.Contains(description, placeholder) {
+			description = strings.ReplaceAll(description, placeholder, value())
+		}
+	}
+	return description
+}
@@ -0,0 +1,426 @@
+package resume
+
+import (
+	"reflect"
+	"testing"
+)
+
+func ptr(s string) *string {
+	return &s
+}
+
+func TestDecide(t *testing.T) {
+	tests := []struct {
+		name         string
+		state        State
+		wantStep     int
+		wantPath     []string
+		wantNotReady []string
+	}{
+		{
+			name: "no availableVersion and no conditions",
+			state: State{
+				TargetVersion: "1.0.1",
+			},
+			wantStep:     StepDetermineResumePoint,
+			wantPath:     []string{"version-unknown", "not-initialized"},
+			wantNotReady: []string{},
+		},
+		{
+			name: "no availableVersion while not initialized",
+			state: State{
+				TargetVersion:   "1.0.1",
+				DeployedVersion: ptr("1.0.0"),
+				Conditions: map[string]string{
+					ConditionReady:       ConditionFalse,
+					ConditionInitialized: ConditionFalse,
+				},
+			},
+			wantStep:     StepDetermineResumePoint,
+			wantPath:     []string{"version-unknown", "not-initialized"},
+			wantNotReady: []string{ConditionReady, ConditionInitialized},
+		},
+		{
+			name: "no availableVersion during the OVN dataplane phase",
+			state: State{
+				TargetVersion:   "1.0.1",
+				DeployedVersion: ptr("1.0.0"),
+				Conditions: map[string]string{
+					ConditionMinorUpdateOVNControlplane: ConditionTrue,
+					ConditionMinorUpdateOVNDataplane:    ConditionFalse,
+				},
+			},
+			wantStep:     StepDeployOVNDataplane,
+			wantPath:     []string{"version-unknown", "ovn-dataplane"},
+			wantNotReady: []string{ConditionMinorUpdateOVNDataplane},
+		},
+		{
+			name: "update available",
+			state: State{
+				TargetVersion:    "1.0.0",
+				AvailableVersion: ptr("1.0.1"),
+				DeployedVersion:  ptr("1.0.0"),
+				Conditions: map[string]string{
+					ConditionReady:                ConditionTrue,
+					ConditionMinorUpdateAvailable: ConditionTrue,
+				},
+			},
+			wantStep:     StepPreUpgradeValidation,
+			wantPath:     []string{"update-not-started", "update-available"},
+			wantNotReady: []string{},
+		},
+		{
+			name: "targetVersion differs from the deployed availableVersion",
+			state: State{
+				TargetVersion:    "1.0.2",
+				AvailableVersion: ptr("1.0.1"),
+				DeployedVersion:  ptr("1.0.1"),
+			},
+			wantStep:     StepPreUpgradeValidation,
+			wantPath:     []string{"update-not-started", "no-update-available"},
+			wantNotReady: []string{},
+		},
+		{
+			name: "update complete without an update available",
+			state: State{
+				TargetVersion:    "1.0.1",
+				AvailableVersion: ptr("1.0.1"),
+				DeployedVersion:  ptr("1.0.1"),
+				Conditions: map[string]string{
+					ConditionReady:                ConditionTrue,
+					ConditionInitialized:          ConditionTrue,
+					ConditionMinorUpdateAvailable: ConditionFalse,
+					ConditionMinorUpdateDataplane: ConditionTrue,
+				},
+			},
+			wantStep:     StepUpdateComplete,
+			wantPath:     []string{"update-targeted", "update-complete"},
+			wantNotReady: []string{ConditionMinorUpdateAvailable},
+		},
+		{
+			name: "targetVersion just set",
+			state: State{
+				TargetVersion:    "1.0.1",
+				AvailableVersion: ptr("1.0.1"),
+				DeployedVersion:  ptr("1.0.0"),
+				Conditions: map[string]string{
+					ConditionReady: ConditionTrue,
+				},
+			},
+			wantStep:     StepMonitorOVNControlplane,
+			wantPath:     []string{"update-targeted", "update-in-progress", "phases-not-reported"},
+			wantNotReady: []string{},
+		},
+		{
+			name: "OVN control plane phase running",
+			state: State{
+				TargetVersion:    "1.0.1",
+				AvailableVersion: ptr("1.0.1"),
+				DeployedVersion:  ptr("1.0.0"),
+				Conditions: map[string]string{
+					ConditionReady:                      ConditionFalse,
+					ConditionMinorUpdateOVNControlplane: ConditionFalse,
+					ConditionMinorUpdateOVNDataplane:    ConditionFalse,
+					ConditionMinorUpdateControlplane:    ConditionFalse,
+					ConditionMinorUpdateDataplane:       ConditionFalse,
+				},
+			},
+			wantStep: StepMonitorOVNControlplane,
+			wantPath: []string{"update-targeted", "update-in-progress", "ovn-controlplane"},
+			wantNotReady: []string{
+				ConditionReady,
+				ConditionMinorUpdateOVNControlplane,
+				ConditionMinorUpdateOVNDataplane,
+				ConditionMinorUpdateControlplane,
+				ConditionMinorUpdateDataplane,
+			},
+		},
+		{
+			name: "Keystone phase running",
+			state: State{
+				TargetVersion:    "1.0.1",
+				AvailableVersion: ptr("1.0.1"),
+				DeployedVersion:  ptr("1.0.0"),
+				Conditions: map[string]string{
+					ConditionMinorUpdateOVNControlplane: ConditionTrue,
+					ConditionMinorUpdateOVNDataplane:    ConditionTrue,
+					ConditionMinorUpdateRabbitMQ:        ConditionTrue,
+					ConditionMinorUpdateKeystone:        ConditionUnknown,
+				},
+			},
+			wantStep:     StepMonitorControlplane,
+			wantPath:     []string{"update-targeted", "update-in-progress", "controlplane"},
+			wantNotReady: []string{ConditionMinorUpdateKeystone},
+		},
+		{
+			name: "dataplane phase pending",
+			state: State{
+				TargetVersion:    "1.0.1",
+				AvailableVersion: ptr("1.0.1"),
+				DeployedVersion:  ptr("1.0.0"),
+				Conditions: map[string]string{
+					ConditionMinorUpdateOVNControlplane: ConditionTrue,
+					ConditionMinorUpdateOVNDataplane:    ConditionTrue,
+					ConditionMinorUpdateControlplane:    ConditionTrue,
+					ConditionMinorUpdateDataplane:       ConditionFalse,
+				},
+			},
+			wantStep:     StepDeployDataplane,
+			wantPath:     []string{"update-targeted", "update-in-progress", "dataplane"},
+			wantNotReady: []string{ConditionMinorUpdateDataplane},
+		},
+		{
+			name: "every phase complete before deployedVersion is recorded",
+			state: State{
+				TargetVersion:    "1.0.1",
+				AvailableVersion: ptr("1.0.1"),
+				DeployedVersion:  ptr("1.0.0"),
+				Conditions: map[string]string{
+					ConditionMinorUpdateOVNControlplane: ConditionTrue,
+					ConditionMinorUpdateOVNDataplane:    ConditionTrue,
+					ConditionMinorUpdateRabbitMQ:        ConditionTrue,
+					ConditionMinorUpdateMariaDB:         ConditionTrue,
+					ConditionMinorUpdateMemcached:       ConditionTrue,
+					ConditionMinorUpdateKeystone:        ConditionTrue,
+					ConditionMinorUpdateControlplane:    ConditionTrue,
+					ConditionMinorUpdateDataplane:       ConditionTrue,
+				},
+			},
+			wantStep:     StepMonitorDataplane,
+			wantPath:     []string{"update-targeted", "update-in-progress", "phases-complete"},
+			wantNotReady: []string{},
+		},
+		{
+			name: "phases partly reported",
+			state: State{
+				TargetVersion:    "1.0.1",
+				AvailableVersion: ptr("1.0.1"),
+				DeployedVersion:  ptr("1.0.0"),
+				Conditions: map[string]string{
+					ConditionMinorUpdateOVNControlplane: ConditionTrue,
+				},
+			},
+			wantStep:     StepPreUpgradeValidation,
+			wantPath:     []string{"update-targeted", "update-in-progress", "unrecognized"},
+			wantNotReady: []string{},
+		},
+		{
+			name: "deployed but not ready",
+			state: State{
+				TargetVersion:    "1.0.1",
+				AvailableVersion: ptr("1.0.1"),
+				DeployedVersion:  ptr("1.0.1"),
+				Conditions: map[string]string{
+					ConditionReady: ConditionFalse,
+					"Custom":       ConditionFalse,
+				},
+			},
+			wantStep:     StepUpdateComplete,
+			wantPath:     []string{"update-targeted", "deployed-not-ready", "verify"},
+			wantNotReady: []string{ConditionReady, "Custom"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision, err := Decide(tt.state)
+			if err != nil {
+				t.Fatalf("Decide() error = %v", err)
+			}
+			if decision.Step != tt.wantStep {
+				t.Errorf("Step = %d, want %d (%s)", decision.Step, tt.wantStep, decision.Explanation)
+			}
+			if decision.StepName != StepName(tt.wantStep) {
+				t.Errorf("StepName = %q, want %q", decision.StepName, StepName(tt.wantStep))
+			}
+			if got := decision.RulePath(); !reflect.DeepEqual(got, tt.wantPath) {
+				t.Errorf("RulePath() = %v, want %v", got, tt.wantPath)
+			}
+			if !reflect.DeepEqual(decision.NotReadyConditions, tt.wantNotReady) {
+				t.Errorf("NotReadyConditions = %v, want %v", decision.NotReadyConditions, tt.wantNotReady)
+			}
+			if decision.TableVersion != TableVersion {
+				t.Errorf("TableVersion = %q, want %q", decision.TableVersion, TableVersion)
+			}
+		})
+	}
+}
+
+func TestDecideExplanation(t *testing.T) {
+	decision, err := Decide(State{
+		TargetVersion:    "1.0.0",
+		AvailableVersion: ptr("1.0.1"),
+		DeployedVersion:  ptr("1.0.0"),
+	})
+	if err != nil {
+		t.Fatalf("Decide() error = %v", err)
+	}
+
+	want := "Update not in progress (targetVersion='1.0.0' != availableVersion='1.0.1'). " +
+		"availableVersion '1.0.1' is newer than deployedVersion '1.0.0'. " +
+		"Resume at step 2 (PreUpgradeValidation)."
+	if decision.Explanation != want {
+		t.Errorf("Explanation = %q, want %q", decision.Explanation, want)
+	}
+}
+
+func TestDecideNoMatch(t *testing.T) {
+	table := Table{
+		Version: "test",
+		Rules: []Rule{{
+			ID:   "parent",
+			When: Match{Versions: []VersionCheck{AvailableKnown}},
+			Children: []Rule{{
+				ID:   "child",
+				When: Match{AllReady: true},
+				Step: StepUpdateComplete,
+			}},
+		}},
+	}
+
+	if _, err := table.Decide(State{TargetVersion: "1.0.0"}); err == nil {
+		t.Error("Decide() with no matching rule returned no error")
+	}
+	if _, err := table.Decide(State{
+		TargetVersion:    "1.0.0",
+		AvailableVersion: ptr("1.0.0"),
+		Conditions:       map[string]string{ConditionReady: ConditionFalse},
+	}); err == nil {
+		t.Error("Decide() with no matching child rule returned no error")
+	}
+}
+
+func TestStepName(t *testing.T) {
+	for step := StepDetermineResumePoint; step <= StepUpdateComplete; step++ {
+		if _, ok := stepNames[step]; !ok {
+			t.Errorf("step %d has no name", step)
+		}
+	}
+	if got := StepName(42); got != "Step42" {
+		t.Errorf("StepName(42) = %q, want %q", got, "Step42")
+	}
+}
+
+// versionStates are the combinations of versions the decision distinguishes
+var versionStates = []struct {
+	name             string
+	availableVersion *string
+	deployedVersion  *string
+}{
+	{"nothing reported", nil, nil},
+	{"only deployedVersion", nil, ptr("1.0.0")},
+	{"target deployed without availableVersion", nil, ptr("1.0.1")},
+	{"target available, nothing deployed", ptr("1.0.1"), nil},
+	{"target available, older deployed", ptr("1.0.1"), ptr("1.0.0")},
+	{"target available and deployed", ptr("1.0.1"), ptr("1.0.1")},
+	{"newer available, target deployed", ptr("1.0.2"), ptr("1.0.1")},
+	{"newer available and deployed", ptr("1.0.2"), ptr("1.0.2")},
+}
+
+// forEachConditionCombination calls fn with every combination of the
+// catalogued conditions being True, False or absent
+func forEachConditionCombination(fn func(conditions map[string]string)) {
+	statuses := []string{ConditionTrue, ConditionFalse, ""}
+	indexes := make([]int, len(Conditions))
+	for {
+		conditions := map[string]string{}
+		for i, cond := range Conditions {
+			if status := statuses[indexes[i]]; status != "" {
+				conditions[cond.Type] = status
+			}
+		}
+		fn(conditions)
+
+		i := 0
+		for ; i < len(indexes); i++ {
+			indexes[i]++
+			if indexes[i] < len(statuses) {
+				break
+			}
+			indexes[i] = 0
+		}
+		if i == len(indexes) {
+			return
+		}
+	}
+}
+
+// earliestPhaseStep returns the step of the first phase condition that is
+// reported and not True, or 0
+func earliestPhaseStep(conditions map[string]string) int {
+	for _, cond := range Conditions {
+		if status, ok := conditions[cond.Type]; cond.Role == RolePhase && ok && status != ConditionTrue {
+			return cond.Step
+		}
+	}
+	return 0
+}
+
+// phasesReported returns the number of phase conditions that are reported
+func phasesReported(conditions map[string]string) int {
+	reported := 0
+	for _, conditionType := range phaseConditions() {
+		if _, ok := conditions[conditionType]; ok {
+			reported++
+		}
+	}
+	return reported
+}
+
+func TestDecideAllConditionCombinations(t *testing.T) {
+	for _, vs := range versionStates {
+		t.Run(vs.name, func(t *testing.T) {
+			forEachConditionCombination(func(conditions map[string]string) {
+				state := State{
+					TargetVersion:    "1.0.1",
+					AvailableVersion: vs.availableVersion,
+					DeployedVersion:  vs.deployedVersion,
+					Conditions:       conditions,
+				}
+
+				decision, err := Decide(state)
+				if err != nil {
+					t.Fatalf("Decide(%v) error = %v", conditions, err)
+				}
+				if decision.Step < StepDetermineResumePoint || decision.Step > StepUpdateComplete {
+					t.Fatalf("Decide(%v) step = %d, out of range", conditions, decision.Step)
+				}
+				if len(decision.Path) == 0 {
+					t.Fatalf("Decide(%v) returned an empty rule path", conditions)
+				}
+
+				targetAvailable := vs.availableVersion != nil && *vs.availableVersion == state.TargetVersion
+				phaseStep := earliestPhaseStep(conditions)
+
+				var want int
+				switch {
+				case vs.availableVersion != nil && !targetAvailable:
+					// The update has not started, whatever the conditions say
+					want = StepPreUpgradeValidation
+				case phaseStep != 0:
+					// A running phase always wins, even without availableVersion
+					want = phaseStep
+				case vs.availableVersion == nil:
+					want = StepDetermineResumePoint
+				case vs.deployedVersion != nil && *vs.deployedVersion == state.TargetVersion:
+					// Deployed, with or without conditions left to verify
+					want = StepUpdateComplete
+				case targetAvailable && phasesReported(conditions) == 0:
+					// Targeted but not deployed, and no phase reported yet
+					want = StepMonitorOVNControlplane
+				case targetAvailable && phasesReported(conditions) == len(phaseConditions()):
+					// Every phase complete, but deployedVersion is still behind
+					want = StepMonitorDataplane
+				case targetAvailable:
+					// Some phases complete and the others not reported
+					want = StepPreUpgradeValidation
+				default:
+					t.Fatalf("Decide(%v) step = %d: combination not classified by the test: %s", conditions, decision.Step, decision.Explanation)
+				}
+				if decision.Step != want {
+					t.Fatalf("Decide(%v) step = %d, want %d: %s", conditions, decision.Step, want, decision.Explanation)
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package resume
+
+import (
+	openstackv1beta1 "github.com/openstack-k8s-operators/openstack-operator/apis/core/v1beta1"
+)
+
+// TableVersion is the version of DefaultTable. Bump it whenever a rule is
+// added, removed or changes its step, so decisions can be traced to the
+// table that made them.
+const TableVersion = "2"
+
+// ConditionRole is how a condition takes part in the resume decision
+type ConditionRole string
+
+const (
+	// RoleReadiness conditions only affect whether the update is complete
+	RoleReadiness ConditionRole = "readiness"
+	// RoleInformational conditions never affect the decision
+	RoleInformational ConditionRole = "informational"
+	// RolePhase conditions track one phase of the minor update and map to
+	// the runbook step that resumes it
+	RolePhase ConditionRole = "phase"
+)
+
+// Condition types reported by the OpenStackVersion CR
+const (
+	ConditionReady                      = "Ready"
+	ConditionInitialized                = string(openstackv1beta1.OpenStackVersionInitialized)
+	ConditionMinorUpdateAvailable       = string(openstackv1beta1.OpenStackVersionMinorUpdateAvailable)
+	ConditionMinorUpdateOVNControlplane = string(openstackv1beta1.OpenStackVersionMinorUpdateOVNControlplane)
+	ConditionMinorUpdateOVNDataplane    = string(openstackv1beta1.OpenStackVersionMinorUpdateOVNDataplane)
+	ConditionMinorUpdateRabbitMQ        = string(openstackv1beta1.OpenStackVersionMinorUpdateRabbitMQ)
+	ConditionMinorUpdateMariaDB         = string(openstackv1beta1.OpenStackVersionMinorUpdateMariaDB)
+	ConditionMinorUpdateMemcached       = string(openstackv1beta1.OpenStackVersionMinorUpdateMemcached)
+	ConditionMinorUpdateKeystone        = string(openstackv1beta1.OpenStackVersionMinorUpdateKeystone)
+	ConditionMinorUpdateControlplane    = string(openstackv1beta1.OpenStackVersionMinorUpdateControlplane)
+	ConditionMinorUpdateDataplane       = string(openstackv1beta1.OpenStackVersionMinorUpdateDataplane)
+)
+
+// ConditionSpec describes one OpenStackVersion condition
+type ConditionSpec struct {
+	Type string
+	Role ConditionRole
+	// Step is the runbook step that resumes a phase condition that is not True
+	Step int
+}
+
+// Conditions catalogues every OpenStackVersion condition. Phase conditions
+// are in the order the operator runs the phases.
+var Conditions = []ConditionSpec{
+	{Type: ConditionReady, Role: RoleReadiness},
+	{Type: ConditionInitialized, Role: RoleReadiness},
+	{Type: ConditionMinorUpdateAvailable, Role: RoleInformational},
+	{Type: ConditionMinorUpdateOVNControlplane, Role: RolePhase, Step: StepMonitorOVNControlplane},
+	{Type: ConditionMinorUpdateOVNDataplane, Role: RolePhase, Step: StepDeployOVNDataplane},
+	{Type: ConditionMinorUpdateRabbitMQ, Role: RolePhase, Step: StepMonitorControlplane},
+	{Type: ConditionMinorUpdateMariaDB, Role: RolePhase, Step: StepMonitorControlplane},
+	{Type: ConditionMinorUpdateMemcached, Role: RolePhase, Step: StepMonitorControlplane},
+	{Type: ConditionMinorUpdateKeystone, Role: RolePhase, Step: StepMonitorControlplane},
+	{Type: ConditionMinorUpdateControlplane, Role: RolePhase, Step: StepMonitorControlplane},
+	{Type: ConditionMinorUpdateDataplane, Role: RolePhase, Step: StepDeployDataplane},
+}
+
+// conditionRoles maps condition types to their role
+var conditionRoles = func() map[string]ConditionRole {
+	roles := make(map[string]ConditionRole, len(Conditions))
+	for _, cond := range Conditions {
+		roles[cond.Type] = cond.Role
+	}
+	return roles
+}()
+
+// phaseConditions returns the types of the phase conditions, in phase order
+func phaseConditions() []string {
+	phases := []string{}
+	for _, cond := range Conditions {
+		if cond.Role == RolePhase {
+			phases = append(phases, cond.Type)
+		}
+	}
+	return phases
+}
+
+// phaseRules resume the first phase of the update that is not complete
+var phaseRules = []Rule{
+	{
+		ID:          "ovn-controlplane",
+		Description: "MinorUpdateOVNControlplane is not True: the OVN control plane update is running.",
+		When:        Match{NotReadyAny: []string{ConditionMinorUpdateOVNControlplane}},
+		Step:        StepMonitorOVNControlplane,
+	},
+	{
+		ID:          "ovn-dataplane",
+		Description: "MinorUpdateOVNDataplane is not True: OVN must be updated on the data plane.",
+		When:        Match{NotReadyAny: []string{ConditionMinorUpdateOVNDataplane}},
+		Step:        StepDeployOVNDataplane,
+	},
+	{
+		ID:          "controlplane",
+		Description: "The control plane update (RabbitMQ, MariaDB, Memcached, Keystone and the remaining services) is not complete.",
+		When: Match{NotReadyAny: []string{
+			ConditionMinorUpdateRabbitMQ,
+			ConditionMinorUpdateMariaDB,
+			ConditionMinorUpdateMemcached,
+			ConditionMinorUpdateKeystone,
+			ConditionMinorUpdateControlplane,
+		}},
+		Step: StepMonitorControlplane,
+	},
+	{
+		ID:          "dataplane",
+		Description: "MinorUpdateDataplane is not True: the data plane must be updated.",
+		When:        Match{NotReadyAny: []string{ConditionMinorUpdateDataplane}},
+		Step:        StepDeployDataplane,
+	},
+}
+
+// withRules returns phaseRules followed by rules
+func withRules(rules ...Rule) []Rule {
+	return append(append([]Rule{}, phaseRules...), rules...)
+}
+
+// DefaultTable decides the resume step of a minor update
+var DefaultTable = Table{
+	Version: TableVersion,
+	Rules: []Rule{
+		{
+			ID:          "version-unknown",
+			Description: "availableVersion is not set, so the operator has not initialized the OpenStackVersion yet or lost track of it.",
+			When:        Match{Versions: []VersionCheck{AvailableUnknown}},
+			Children: withRules(Rule{
+				ID:          "not-initialized",
+				Description: "No minor update phase is running. Wait for the operator to report availableVersion and determine the resume point again.",
+				Step:        StepDetermineResumePoint,
+			}),
+		},
+		{
+			ID:          "update-not-started",
+			Description: "Update not in progress (targetVersion='{targetVersion}' != availableVersion='{availableVersion}').",
+			When:        Match{Versions: []VersionCheck{TargetNotAvailable}},
+			Children: []Rule{
+				{
+					ID:          "update-available",
+					Description: "availableVersion '{availableVersion}' is newer than deployedVersion '{deployedVersion}'.",
+					When:        Match{Versions: []VersionCheck{AvailableNewer}},
+					Step:        StepPreUpgradeValidation,
+				},
+				{
+					ID:          "no-update-available",
+					Description: "availableVersion '{availableVersion}' is already deployed.",
+					Step:        StepPreUpgradeValidation,
+				},
+			},
+		},
+		{
+			ID:          "update-targeted",
+			Description: "targetVersion='{targetVersion}' == availableVersion='{availableVersion}'.",
+			When:        Match{Versions: []VersionCheck{TargetIsAvailable}},
+			Children: []Rule{
+				{
+					ID:          "update-complete",
+					Description: "Update complete (targetVersion == deployedVersion and all conditions ready).",
+					When:        Match{Versions: []VersionCheck{TargetIsDeployed}, AllReady: true},
+					Step:        StepUpdateComplete,
+				},
+				{
+					ID:          "update-in-progress",
+					Description: "Update in progress (deployedVersion='{deployedVersion}').",
+					When:        Match{Versions: []VersionCheck{TargetNotDeployed}},
+					Children: withRules(
+						Rule{
+							ID:          "phases-not-reported",
+							Description: "The operator has not reported any minor update phase yet.",
+							When:        Match{AllAbsent: phaseConditions()},
+							Step:        StepMonitorOVNControlplane,
+						},
+						Rule{
+							ID:          "phases-complete",
+							Description: "Every minor update phase is complete but the operator has not recorded deployedVersion yet.",
+							When:        Match{AllTrue: phaseConditions()},
+							Step:        StepMonitorDataplane,
+						},
+						Rule{
+							ID:          "unrecognized",
+							Description: "Could not determine the resume point from the conditions; notReadyConditions={notReadyConditions}.",
+							Step:        StepPreUpgradeValidation,
+						},
+					),
+				},
+				{
+					ID:          "deployed-not-ready",
+					Description: "targetVersion is deployed but conditions are not ready: {notReadyConditions}.",
+					Children: withRules(Rule{
+						ID:          "verify",
+						Description: "No minor update phase is running; verify the deployment.",
+						Step:        StepUpdateComplete,
+					}),
+				},
+			},
+		},
+	},
+}